@@ -0,0 +1,108 @@
+package common
+
+import "math/bits"
+
+// Bitset is a fixed-size set of small non-negative ints.
+type Bitset struct {
+	words []uint64
+	n     int
+}
+
+func NewBitset(n int) *Bitset {
+	return &Bitset{words: make([]uint64, (n+63)/64), n: n}
+}
+
+// Cap is the number of bits, ie one more than the largest int the set can hold.
+func (b *Bitset) Cap() int {
+	return b.n
+}
+
+// Set adds i and reports whether it was newly added.
+func (b *Bitset) Set(i int) bool {
+	w, m := i>>6, uint64(1)<<(i&63)
+	if b.words[w]&m != 0 {
+		return false
+	}
+	b.words[w] |= m
+	return true
+}
+
+func (b *Bitset) Test(i int) bool {
+	return b.words[i>>6]&(uint64(1)<<(i&63)) != 0
+}
+
+func (b *Bitset) Clear(i int) {
+	b.words[i>>6] &^= uint64(1) << (i & 63)
+}
+
+func (b *Bitset) Count() int {
+	c := 0
+	for _, w := range b.words {
+		c += bits.OnesCount64(w)
+	}
+	return c
+}
+
+// Reset clears every bit without reallocating.
+func (b *Bitset) Reset() {
+	clear(b.words)
+}
+
+// GridBitset is a Bitset keyed by grid cell, with an optional number of layers per cell (eg one per heading).
+type GridBitset struct {
+	bits          *Bitset
+	width, height int
+	layers        int
+}
+
+func NewGridBitset(width, height, layers int) *GridBitset {
+	layers = max(layers, 1)
+	return &GridBitset{bits: NewBitset(width * height * layers), width: width, height: height, layers: layers}
+}
+
+func (g *GridBitset) index(x, y, layer int) int {
+	return (y*g.width+x)*g.layers + layer
+}
+
+func (g *GridBitset) In(x, y int) bool {
+	return x >= 0 && x < g.width && y >= 0 && y < g.height
+}
+
+// Set marks (x, y, layer) and reports whether it was newly marked.
+func (g *GridBitset) Set(x, y, layer int) bool {
+	return g.bits.Set(g.index(x, y, layer))
+}
+
+func (g *GridBitset) Test(x, y, layer int) bool {
+	return g.bits.Test(g.index(x, y, layer))
+}
+
+func (g *GridBitset) Clear(x, y, layer int) {
+	g.bits.Clear(g.index(x, y, layer))
+}
+
+// Count is the number of marked (x, y, layer) triples.
+func (g *GridBitset) Count() int {
+	return g.bits.Count()
+}
+
+// CountCells is the number of cells with at least one layer marked.
+func (g *GridBitset) CountCells() int {
+	if g.layers == 1 {
+		return g.Count()
+	}
+	c := 0
+	for i := 0; i < g.width*g.height; i++ {
+		for l := range g.layers {
+			if g.bits.Test(i*g.layers + l) {
+				c++
+				break
+			}
+		}
+	}
+	return c
+}
+
+func (g *GridBitset) Reset() {
+	g.bits.Reset()
+}
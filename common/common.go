@@ -2,20 +2,80 @@ package common
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"log/slog"
+	"math/rand/v2"
 	"os"
+	"reflect"
+
+	"go.coldcutz.net/advent2024/common/parse"
 )
 
 type Opts struct {
 	Part  int    `short:"p" description:"part 1 or 2" required:"true"`
 	Input string `short:"i" description:"input file" required:"true"`
+	Seed  uint64 `long:"seed" description:"seed for solvers that use randomness; 0 picks one (it's logged either way)"`
+
+	// day 1
+	Columns  string   `long:"columns" description:"comma-separated columns to compare, from 1 (default all; parts 1 and 2 use the first two)"`
+	Metrics  []string `long:"metric" description:"what part 3 computes between columns; repeatable (default all)" choice:"l1" choice:"l2" choice:"intersection" choice:"jaccard" choice:"similarity"`
+	MemLimit int      `long:"mem-limit" description:"memory budget in MiB for sorting; bigger inputs are sorted on disk (0 for no limit)" default:"1024"`
+
+	// day 2
+	MinStep   int  `long:"min-step" description:"smallest allowed step between levels" default:"1"`
+	MaxStep   int  `long:"max-step" description:"biggest allowed step between levels" default:"3"`
+	NonStrict bool `long:"non-strict" description:"allow equal adjacent levels"`
+	Tolerance int  `long:"tolerance" description:"how many levels may be removed to make a report safe (default 0 for part 1, 1 for part 2)" default:"-1"`
+
+	// day 3
+	Trace      bool   `long:"trace" description:"print every instruction as it's executed or skipped"`
+	MinDigits  int    `long:"min-digits" description:"fewest digits an operand may have" default:"1"`
+	MaxDigits  int    `long:"max-digits" description:"most digits an operand may have (0 for no limit)" default:"3"`
+	Signed     bool   `long:"signed" description:"allow operands to have a sign"`
+	Spaces     bool   `long:"spaces" description:"allow spaces around parentheses and commas"`
+	NearMisses bool   `long:"near-misses" description:"log instructions that were rejected, and why"`
+	Annotate   string `long:"annotate" description:"write the input back out with its instructions highlighted and a running sum" optional:"yes" optional-value:"ansi" choice:"ansi" choice:"html"`
+
+	// day 4
+	Words string `long:"words" description:"comma-separated words to search for" default:"XMAS"`
+	Wrap  bool   `long:"wrap" description:"let words wrap around the edges of the grid"`
+	Bent  bool   `long:"bent" description:"find boggle-style words that can turn at every letter"`
+
+	Explain string `long:"explain" description:"print how the answer was worked out (where supported)" optional:"yes" optional-value:"table" choice:"table" choice:"csv"`
+	Top     int    `long:"top" description:"how many of the biggest contributors --explain lists" default:"10"`
+
+	Viz    string `long:"viz" description:"visualize the solution (where supported)" choice:"ansi" choice:"text"`
+	FPS    int    `long:"fps" description:"frame rate for --viz ansi and --render *.gif" default:"20"`
+	Render string `long:"render" description:"render the solution to an image file: .gif, .png or .svg (where supported)"`
 }
 
 type Solutions map[int]func(ctx context.Context, log *slog.Logger, opts Opts) error
 
+// Generator writes a random, valid puzzle input to w. What size means is up to each day, but bigger is bigger.
+type Generator func(w io.Writer, size int, rng *rand.Rand) error
+
+// NewRand returns the source of randomness for a run, seeded from --seed or, if that's unset, from a fresh random
+// seed. The seed is logged so that any run can be replayed.
+func NewRand(opts Opts, log *slog.Logger) *rand.Rand {
+	return SeededRand(opts.Seed, log)
+}
+
+// SeededRand is NewRand for callers that don't have Opts.
+func SeededRand(seed uint64, log *slog.Logger) *rand.Rand {
+	for seed == 0 {
+		seed = rand.Uint64()
+	}
+	log.Info("using seed", "seed", seed)
+	return rand.New(rand.NewPCG(seed, seed))
+}
+
+func OpenInput(opts Opts) (*os.File, error) {
+	return os.Open(opts.Input)
+}
+
 func ReadAllInput(opts Opts) ([]byte, error) {
-	f, err := os.Open(opts.Input)
+	f, err := OpenInput(opts)
 	if err != nil {
 		return nil, err
 	}
@@ -23,3 +83,39 @@ func ReadAllInput(opts Opts) ([]byte, error) {
 
 	return io.ReadAll(f)
 }
+
+// Fixture generates an input into a file in dir and returns the default Opts set up to read it, for feeding generated inputs to
+// solvers from tests and benchmarks.
+func Fixture(gen Generator, size int, seed uint64, dir string) (Opts, error) {
+	f, err := os.CreateTemp(dir, "input-*.txt")
+	if err != nil {
+		return Opts{}, err
+	}
+	defer f.Close()
+
+	if err := gen(f, size, rand.New(rand.NewPCG(seed, seed))); err != nil {
+		return Opts{}, err
+	}
+	if err := f.Close(); err != nil {
+		return Opts{}, err
+	}
+	opts := DefaultOpts()
+	opts.Input, opts.Seed = f.Name(), seed
+	return opts, nil
+}
+
+// DefaultOpts is Opts with every field that has a `default` tag set from it, the way the flag parser would.
+func DefaultOpts() Opts {
+	var opts Opts
+	v := reflect.ValueOf(&opts).Elem()
+	for _, f := range reflect.VisibleFields(v.Type()) {
+		def, ok := f.Tag.Lookup("default")
+		if !ok {
+			continue
+		}
+		if err := parse.SetField(v.FieldByIndex(f.Index), def); err != nil {
+			panic(fmt.Sprintf("default for %s: %v", f.Name, err))
+		}
+	}
+	return opts
+}
@@ -0,0 +1,73 @@
+package common
+
+import (
+	"cmp"
+	"iter"
+	"maps"
+	"slices"
+)
+
+// Counter is a multiset: item -> number of occurrences.
+type Counter[T comparable] map[T]int
+
+func NewCounter[T comparable](items ...T) Counter[T] {
+	c := make(Counter[T], len(items))
+	for _, item := range items {
+		c[item]++
+	}
+	return c
+}
+
+func (c Counter[T]) Add(items ...T) {
+	for _, item := range items {
+		c[item]++
+	}
+}
+
+func (c Counter[T]) AddN(item T, n int) {
+	c[item] += n
+	if c[item] <= 0 {
+		delete(c, item)
+	}
+}
+
+func (c Counter[T]) Count(item T) int {
+	return c[item]
+}
+
+// Total is the sum of all counts.
+func (c Counter[T]) Total() int {
+	total := 0
+	for _, n := range c {
+		total += n
+	}
+	return total
+}
+
+func (c Counter[T]) All() iter.Seq2[T, int] {
+	return maps.All(c)
+}
+
+type Count[T any] struct {
+	Item T
+	N    int
+}
+
+// MostCommon returns the n highest counts (all of them if n < 0). Ties are broken by cmp on the item so the result
+// is reproducible.
+func (c Counter[T]) MostCommon(n int, cmpItems func(a, b T) int) []Count[T] {
+	counts := make([]Count[T], 0, len(c))
+	for item, cnt := range c {
+		counts = append(counts, Count[T]{item, cnt})
+	}
+	slices.SortFunc(counts, func(a, b Count[T]) int {
+		if d := cmp.Compare(b.N, a.N); d != 0 {
+			return d
+		}
+		return cmpItems(a.Item, b.Item)
+	})
+	if n >= 0 && n < len(counts) {
+		counts = counts[:n]
+	}
+	return counts
+}
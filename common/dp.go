@@ -0,0 +1,47 @@
+package common
+
+// Table is a dense rows x cols grid of DP states.
+type Table[V any] struct {
+	rows, cols int
+	cells      []V
+}
+
+func NewTable[V any](rows, cols int) *Table[V] {
+	return &Table[V]{rows: rows, cols: cols, cells: make([]V, rows*cols)}
+}
+
+func (t *Table[V]) Rows() int { return t.rows }
+func (t *Table[V]) Cols() int { return t.cols }
+
+func (t *Table[V]) In(r, c int) bool {
+	return r >= 0 && r < t.rows && c >= 0 && c < t.cols
+}
+
+func (t *Table[V]) At(r, c int) V {
+	return t.cells[r*t.cols+c]
+}
+
+func (t *Table[V]) Set(r, c int, v V) {
+	t.cells[r*t.cols+c] = v
+}
+
+// GridDP fills a rows x cols table in row-major order. fn computes cell (r, c) and may read any cell that comes
+// before it, ie anything above it or to its left.
+func GridDP[V any](rows, cols int, fn func(t *Table[V], r, c int) V) *Table[V] {
+	t := NewTable[V](rows, cols)
+	for r := range rows {
+		for c := range cols {
+			t.Set(r, c, fn(t, r, c))
+		}
+	}
+	return t
+}
+
+// SeqDP fills dp[0..n) in order. fn computes dp[i] and may read dp[:i].
+func SeqDP[V any](n int, fn func(dp []V, i int) V) []V {
+	dp := make([]V, n)
+	for i := range n {
+		dp[i] = fn(dp[:i], i)
+	}
+	return dp
+}
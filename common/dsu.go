@@ -0,0 +1,114 @@
+package common
+
+// DSU is a disjoint-set union (union-find) over arbitrary comparable items, with path compression and union by
+// rank. Items are added implicitly the first time they're seen.
+type DSU[T comparable] struct {
+	index map[T]int
+	items []T
+	uf    unionFind
+}
+
+func NewDSU[T comparable]() *DSU[T] {
+	return &DSU[T]{index: map[T]int{}}
+}
+
+func (d *DSU[T]) id(x T) int {
+	if i, ok := d.index[x]; ok {
+		return i
+	}
+	i := d.uf.add()
+	d.index[x] = i
+	d.items = append(d.items, x)
+	return i
+}
+
+// Add makes x a singleton set if it isn't in one already.
+func (d *DSU[T]) Add(x T) {
+	d.id(x)
+}
+
+// Find returns the representative of x's set.
+func (d *DSU[T]) Find(x T) T {
+	return d.items[d.uf.find(d.id(x))]
+}
+
+// Union merges the sets containing a and b, reporting whether they were separate.
+func (d *DSU[T]) Union(a, b T) bool {
+	return d.uf.union(d.id(a), d.id(b))
+}
+
+func (d *DSU[T]) Same(a, b T) bool {
+	return d.uf.find(d.id(a)) == d.uf.find(d.id(b))
+}
+
+// Size is the number of items in x's set.
+func (d *DSU[T]) Size(x T) int {
+	return d.uf.size[d.uf.find(d.id(x))]
+}
+
+// Sets is the number of disjoint sets.
+func (d *DSU[T]) Sets() int {
+	return d.uf.sets
+}
+
+// Groups returns every set, keyed by its representative.
+func (d *DSU[T]) Groups() map[T][]T {
+	groups := map[T][]T{}
+	for i, x := range d.items {
+		root := d.items[d.uf.find(i)]
+		groups[root] = append(groups[root], x)
+	}
+	return groups
+}
+
+// unionFind is the dense core of DSU, over the ints [0, n)
+type unionFind struct {
+	parent, rank, size []int
+	sets               int
+}
+
+func newUnionFind(n int) unionFind {
+	uf := unionFind{parent: make([]int, n), rank: make([]int, n), size: make([]int, n), sets: n}
+	for i := range n {
+		uf.parent[i] = i
+		uf.size[i] = 1
+	}
+	return uf
+}
+
+func (uf *unionFind) add() int {
+	i := len(uf.parent)
+	uf.parent = append(uf.parent, i)
+	uf.rank = append(uf.rank, 0)
+	uf.size = append(uf.size, 1)
+	uf.sets++
+	return i
+}
+
+func (uf *unionFind) find(x int) int {
+	root := x
+	for uf.parent[root] != root {
+		root = uf.parent[root]
+	}
+	for uf.parent[x] != root {
+		uf.parent[x], x = root, uf.parent[x]
+	}
+	return root
+}
+
+func (uf *unionFind) union(a, b int) bool {
+	a, b = uf.find(a), uf.find(b)
+	if a == b {
+		return false
+	}
+	if uf.rank[a] < uf.rank[b] {
+		a, b = b, a
+	}
+	uf.parent[b] = a
+	uf.size[a] += uf.size[b]
+	if uf.rank[a] == uf.rank[b] {
+		uf.rank[a]++
+	}
+	uf.sets--
+	return true
+}
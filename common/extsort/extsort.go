@@ -0,0 +1,228 @@
+package extsort
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"io"
+	"iter"
+	"os"
+	"slices"
+
+	"go.coldcutz.net/advent2024/common/numth"
+)
+
+// Sorter sorts a stream of integers in a bounded amount of memory. Values are buffered until the buffer reaches the
+// memory limit, then sorted and spilled to a temp file as a run; Sorted merges the runs back together, a batch of at
+// most maxFanIn at a time so only that many files are ever open. Inputs that fit never touch the disk.
+type Sorter[T numth.Integer] struct {
+	limit int // values per run, or 0 for no limit
+	buf   []T
+	runs  []string // temp file names
+	n     int
+}
+
+// maxFanIn is how many runs are merged at once. More runs than this are merged in several passes.
+const maxFanIn = 64
+
+// New makes a Sorter that keeps at most memLimit bytes of values in memory at once. memLimit <= 0 means no limit.
+func New[T numth.Integer](memLimit int64) *Sorter[T] {
+	s := &Sorter[T]{}
+	if memLimit > 0 {
+		s.limit = int(max(memLimit/8, 1))
+	}
+	return s
+}
+
+func (s *Sorter[T]) Add(v T) error {
+	s.buf = append(s.buf, v)
+	s.n++
+	if s.limit > 0 && len(s.buf) >= s.limit {
+		return s.spill()
+	}
+	return nil
+}
+
+// Len is how many values have been added.
+func (s *Sorter[T]) Len() int {
+	return s.n
+}
+
+// Runs is how many sorted runs are on disk.
+func (s *Sorter[T]) Runs() int {
+	return len(s.runs)
+}
+
+func (s *Sorter[T]) spill() error {
+	slices.Sort(s.buf)
+	name, err := writeRun(slices.Values(s.buf))
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, name)
+	s.buf = s.buf[:0]
+	return nil
+}
+
+// mergeRuns replaces the first k runs with a single run of their merged values
+func (s *Sorter[T]) mergeRuns(k int) error {
+	batch := s.runs[:k]
+	vals, valsErr := merge[T](batch)
+	name, err := writeRun(vals)
+	if err == nil {
+		err = valsErr()
+	}
+	if err != nil {
+		if name != "" {
+			os.Remove(name)
+		}
+		return err
+	}
+
+	var errs []error
+	for _, old := range batch {
+		errs = append(errs, os.Remove(old))
+	}
+	s.runs = append(s.runs[k:], name)
+	return errors.Join(errs...)
+}
+
+// Sorted returns every value added, in ascending order. Call the returned func afterwards to check for errors
+// reading the runs back. Don't Add after calling Sorted.
+func (s *Sorter[T]) Sorted() (iter.Seq[T], func() error) {
+	if len(s.runs) == 0 {
+		slices.Sort(s.buf)
+		return slices.Values(s.buf), func() error { return nil }
+	}
+
+	var err error
+	if len(s.buf) > 0 {
+		err = s.spill()
+	}
+	for err == nil && len(s.runs) > maxFanIn {
+		err = s.mergeRuns(maxFanIn)
+	}
+	if err != nil {
+		return func(func(T) bool) {}, func() error { return err }
+	}
+	return merge[T](s.runs)
+}
+
+// Close removes the sorter's temp files.
+func (s *Sorter[T]) Close() error {
+	var errs []error
+	for _, name := range s.runs {
+		errs = append(errs, os.Remove(name))
+	}
+	s.runs, s.buf = nil, nil
+	return errors.Join(errs...)
+}
+
+// writeRun writes vals to a new temp file and returns its name. On error the file is removed again.
+func writeRun[T numth.Integer](vals iter.Seq[T]) (string, error) {
+	f, err := os.CreateTemp("", "extsort-*")
+	if err != nil {
+		return "", err
+	}
+
+	w := bufio.NewWriter(f)
+	var b [8]byte
+	for v := range vals {
+		binary.LittleEndian.PutUint64(b[:], uint64(v))
+		if _, err = w.Write(b[:]); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// merge yields the values of the named runs in order. The files are only open while the sequence is being
+// iterated. Call the returned func afterwards to check for errors.
+func merge[T numth.Integer](names []string) (iter.Seq[T], func() error) {
+	var err error
+	seq := func(yield func(T) bool) {
+		h := make(runHeap[T], 0, len(names))
+		defer func() {
+			for _, r := range h {
+				r.f.Close()
+			}
+		}()
+		for _, name := range names {
+			var f *os.File
+			if f, err = os.Open(name); err != nil {
+				return
+			}
+			r := &run[T]{f: f, r: bufio.NewReader(f)}
+			var ok bool
+			if ok, err = r.next(); err != nil || !ok {
+				f.Close()
+				if err != nil {
+					return
+				}
+				continue
+			}
+			h = append(h, r)
+		}
+		heap.Init(&h)
+		for len(h) > 0 {
+			r := h[0]
+			if !yield(r.head) {
+				return
+			}
+			var ok bool
+			if ok, err = r.next(); err != nil {
+				return
+			}
+			if ok {
+				heap.Fix(&h, 0)
+			} else {
+				heap.Pop(&h)
+				r.f.Close()
+			}
+		}
+	}
+	return seq, func() error { return err }
+}
+
+// run is a cursor over one spilled run
+type run[T numth.Integer] struct {
+	f    *os.File
+	r    *bufio.Reader
+	head T
+}
+
+func (r *run[T]) next() (bool, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(r.r, b[:]); err != nil {
+		if err == io.EOF {
+			return false, nil
+		}
+		return false, err
+	}
+	r.head = T(binary.LittleEndian.Uint64(b[:]))
+	return true, nil
+}
+
+type runHeap[T numth.Integer] []*run[T]
+
+func (h runHeap[T]) Len() int           { return len(h) }
+func (h runHeap[T]) Less(i, j int) bool { return h[i].head < h[j].head }
+func (h runHeap[T]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *runHeap[T]) Push(x any)        { *h = append(*h, x.(*run[T])) }
+func (h *runHeap[T]) Pop() any {
+	old := *h
+	r := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return r
+}
@@ -0,0 +1,67 @@
+package extsort
+
+import (
+	"math/rand/v2"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestSorted(t *testing.T) {
+	tests := []struct {
+		name     string
+		memLimit int64
+		n        int
+		wantRuns int // before Sorted, which merges them down to at most maxFanIn
+	}{
+		{name: "empty", memLimit: 80, n: 0},
+		{name: "in memory", memLimit: 80, n: 9},
+		{name: "no limit", memLimit: 0, n: 1000},
+		{name: "one run", memLimit: 80, n: 10, wantRuns: 1},
+		{name: "one pass", memLimit: 80, n: 10 * maxFanIn, wantRuns: maxFanIn},
+		{name: "several passes", memLimit: 80, n: 10*maxFanIn*3 + 5, wantRuns: maxFanIn * 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("TMPDIR", dir)
+
+			rng := rand.New(rand.NewPCG(1, 2))
+			s := New[int](tt.memLimit)
+			var want []int
+			for range tt.n {
+				v := rng.IntN(1000) - 500
+				want = append(want, v)
+				if err := s.Add(v); err != nil {
+					t.Fatal(err)
+				}
+			}
+			slices.Sort(want)
+			if s.Runs() != tt.wantRuns {
+				t.Errorf("Runs() = %d, want %d", s.Runs(), tt.wantRuns)
+			}
+
+			// Sorted is called for each metric in day 1, so check it works more than once
+			for range 2 {
+				seq, seqErr := s.Sorted()
+				got := slices.Collect(seq)
+				if err := seqErr(); err != nil {
+					t.Fatal(err)
+				}
+				if !slices.Equal(got, want) {
+					t.Fatalf("got %d values out of order or missing, want %d", len(got), len(want))
+				}
+			}
+			if s.Runs() > maxFanIn {
+				t.Errorf("%d runs left after Sorted, want at most %d", s.Runs(), maxFanIn)
+			}
+
+			if err := s.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if left, err := os.ReadDir(dir); err != nil || len(left) > 0 {
+				t.Errorf("temp files left after Close: %v %v", left, err)
+			}
+		})
+	}
+}
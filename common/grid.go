@@ -0,0 +1,35 @@
+package common
+
+// Point is a grid coordinate: X is the column and Y the row, with Y growing downwards.
+type Point struct {
+	X, Y int
+}
+
+func (p Point) Add(o Point) Point {
+	return Point{p.X + o.X, p.Y + o.Y}
+}
+
+func (p Point) Scale(k int) Point {
+	return Point{p.X * k, p.Y * k}
+}
+
+var (
+	Up        = Point{0, -1}
+	Down      = Point{0, 1}
+	Left      = Point{-1, 0}
+	Right     = Point{1, 0}
+	UpLeft    = Point{-1, -1}
+	UpRight   = Point{1, -1}
+	DownLeft  = Point{-1, 1}
+	DownRight = Point{1, 1}
+
+	// Dirs4 is the orthogonal directions, clockwise from up.
+	Dirs4 = []Point{Up, Right, Down, Left}
+	// Dirs8 is all eight directions, clockwise from up.
+	Dirs8 = []Point{Up, UpRight, Right, DownRight, Down, DownLeft, Left, UpLeft}
+)
+
+// InGrid reports whether p is a cell of g. Rows may be ragged.
+func InGrid[G ~[][]T, T any](g G, p Point) bool {
+	return p.Y >= 0 && p.Y < len(g) && p.X >= 0 && p.X < len(g[p.Y])
+}
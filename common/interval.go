@@ -0,0 +1,217 @@
+package common
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"sort"
+	"strings"
+
+	"go.coldcutz.net/advent2024/common/numth"
+)
+
+// Interval is the inclusive integer range [Lo, Hi].
+type Interval[T numth.Integer] struct {
+	Lo, Hi T
+}
+
+func (iv Interval[T]) Empty() bool {
+	return iv.Hi < iv.Lo
+}
+
+// Len is the number of integers in the interval, capped at the largest T for intervals too wide to count.
+func (iv Interval[T]) Len() T {
+	if iv.Empty() {
+		return 0
+	}
+	top := numth.MaxOf[T]()
+	// Hi-Lo+1 > top, rearranged so neither side overflows
+	if (iv.Lo < 0 && iv.Hi >= top+iv.Lo) || (iv.Lo >= 0 && iv.Hi-iv.Lo >= top) {
+		return top
+	}
+	return iv.Hi - iv.Lo + 1
+}
+
+func (iv Interval[T]) Contains(x T) bool {
+	return iv.Lo <= x && x <= iv.Hi
+}
+
+func (iv Interval[T]) Overlaps(o Interval[T]) bool {
+	return iv.Lo <= o.Hi && o.Lo <= iv.Hi
+}
+
+func (iv Interval[T]) Intersect(o Interval[T]) Interval[T] {
+	return Interval[T]{max(iv.Lo, o.Lo), min(iv.Hi, o.Hi)}
+}
+
+// Split cuts the interval so that each cut point in it starts a new piece. Cuts outside (Lo, Hi] are ignored.
+func (iv Interval[T]) Split(cuts ...T) []Interval[T] {
+	cuts = slices.Clone(cuts)
+	slices.Sort(cuts)
+	pieces := []Interval[T]{}
+	lo := iv.Lo
+	for _, c := range slices.Compact(cuts) {
+		if c <= lo || c > iv.Hi {
+			continue
+		}
+		pieces = append(pieces, Interval[T]{lo, c - 1})
+		lo = c
+	}
+	return append(pieces, Interval[T]{lo, iv.Hi})
+}
+
+func (iv Interval[T]) String() string {
+	return fmt.Sprintf("[%d,%d]", iv.Lo, iv.Hi)
+}
+
+// IntervalSet is a set of integers stored as sorted, disjoint, non-adjacent intervals. Membership queries are
+// O(log n) in the number of intervals; updates are O(n) in the worst case. The zero value is an empty set.
+type IntervalSet[T numth.Integer] struct {
+	ivs []Interval[T]
+}
+
+func NewIntervalSet[T numth.Integer](ivs ...Interval[T]) *IntervalSet[T] {
+	s := &IntervalSet[T]{}
+	for _, iv := range ivs {
+		s.Insert(iv.Lo, iv.Hi)
+	}
+	return s
+}
+
+// Len is the number of disjoint intervals.
+func (s *IntervalSet[T]) Len() int {
+	return len(s.ivs)
+}
+
+// Total is how many integers the set covers, capped like Interval.Len.
+func (s *IntervalSet[T]) Total() T {
+	var total T
+	top := numth.MaxOf[T]()
+	for _, iv := range s.ivs {
+		n := iv.Len()
+		if n > top-total {
+			return top
+		}
+		total += n
+	}
+	return total
+}
+
+func (s *IntervalSet[T]) All() iter.Seq[Interval[T]] {
+	return slices.Values(s.ivs)
+}
+
+func (s *IntervalSet[T]) Clone() *IntervalSet[T] {
+	return &IntervalSet[T]{ivs: slices.Clone(s.ivs)}
+}
+
+// search returns the index of the first interval that ends at or after x
+func (s *IntervalSet[T]) search(x T) int {
+	return sort.Search(len(s.ivs), func(i int) bool { return s.ivs[i].Hi >= x })
+}
+
+func (s *IntervalSet[T]) Contains(x T) bool {
+	i := s.search(x)
+	return i < len(s.ivs) && s.ivs[i].Lo <= x
+}
+
+// ContainsRange reports whether every integer in [lo, hi] is in the set.
+func (s *IntervalSet[T]) ContainsRange(lo, hi T) bool {
+	i := s.search(lo)
+	return i < len(s.ivs) && s.ivs[i].Lo <= lo && hi <= s.ivs[i].Hi
+}
+
+// Overlaps reports whether any integer in [lo, hi] is in the set.
+func (s *IntervalSet[T]) Overlaps(lo, hi T) bool {
+	i := s.search(lo)
+	return i < len(s.ivs) && s.ivs[i].Lo <= hi
+}
+
+// Insert adds [lo, hi], merging it with any intervals it overlaps or touches.
+func (s *IntervalSet[T]) Insert(lo, hi T) {
+	if hi < lo {
+		return
+	}
+	// first interval that could touch lo, ie ends at or after lo-1. The +1 and -1 only run where they can't
+	// overflow: Hi < lo means Hi isn't the largest T, and Lo > hi means Lo isn't the smallest.
+	i := sort.Search(len(s.ivs), func(i int) bool { return s.ivs[i].Hi >= lo || s.ivs[i].Hi+1 == lo })
+	j := i
+	for j < len(s.ivs) && (s.ivs[j].Lo <= hi || s.ivs[j].Lo-1 == hi) {
+		lo, hi = min(lo, s.ivs[j].Lo), max(hi, s.ivs[j].Hi)
+		j++
+	}
+	s.ivs = slices.Replace(s.ivs, i, j, Interval[T]{lo, hi})
+}
+
+// Remove deletes [lo, hi] from the set, splitting intervals as needed.
+func (s *IntervalSet[T]) Remove(lo, hi T) {
+	if hi < lo {
+		return
+	}
+	i := s.search(lo)
+	j := i
+	var keep []Interval[T]
+	for j < len(s.ivs) && s.ivs[j].Lo <= hi {
+		iv := s.ivs[j]
+		// iv.Lo < lo and iv.Hi > hi keep lo-1 and hi+1 in range
+		if iv.Lo < lo {
+			keep = append(keep, Interval[T]{iv.Lo, lo - 1})
+		}
+		if iv.Hi > hi {
+			keep = append(keep, Interval[T]{hi + 1, iv.Hi})
+		}
+		j++
+	}
+	s.ivs = slices.Replace(s.ivs, i, j, keep...)
+}
+
+// Merge adds all of o to s.
+func (s *IntervalSet[T]) Merge(o *IntervalSet[T]) {
+	for _, iv := range o.ivs {
+		s.Insert(iv.Lo, iv.Hi)
+	}
+}
+
+func (s *IntervalSet[T]) Union(o *IntervalSet[T]) *IntervalSet[T] {
+	out := s.Clone()
+	out.Merge(o)
+	return out
+}
+
+func (s *IntervalSet[T]) Intersect(o *IntervalSet[T]) *IntervalSet[T] {
+	out := &IntervalSet[T]{}
+	for i, j := 0, 0; i < len(s.ivs) && j < len(o.ivs); {
+		a, b := s.ivs[i], o.ivs[j]
+		if a.Overlaps(b) {
+			out.ivs = append(out.ivs, a.Intersect(b))
+		}
+		if a.Hi < b.Hi {
+			i++
+		} else {
+			j++
+		}
+	}
+	return out
+}
+
+// Subtract is everything in s that isn't in o.
+func (s *IntervalSet[T]) Subtract(o *IntervalSet[T]) *IntervalSet[T] {
+	out := s.Clone()
+	for _, iv := range o.ivs {
+		out.Remove(iv.Lo, iv.Hi)
+	}
+	return out
+}
+
+// Complement is everything in [lo, hi] that isn't in s.
+func (s *IntervalSet[T]) Complement(lo, hi T) *IntervalSet[T] {
+	return NewIntervalSet(Interval[T]{lo, hi}).Subtract(s)
+}
+
+func (s *IntervalSet[T]) String() string {
+	parts := make([]string, len(s.ivs))
+	for i, iv := range s.ivs {
+		parts[i] = iv.String()
+	}
+	return "{" + strings.Join(parts, " ") + "}"
+}
@@ -0,0 +1,117 @@
+package common
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+type op8 struct {
+	remove bool
+	lo, hi int8
+}
+
+func TestIntervalSetEdges(t *testing.T) {
+	tests := []struct {
+		name  string
+		ops   []op8
+		want  string
+		total int8
+	}{
+		{name: "insert to max over a smaller one", ops: []op8{{false, 5, 10}, {false, 0, math.MaxInt8}}, want: "{[0,127]}", total: 127},
+		{name: "insert from min over a smaller one", ops: []op8{{false, -10, -5}, {false, math.MinInt8, 0}}, want: "{[-128,0]}", total: 127},
+		{name: "touching at max", ops: []op8{{false, 127, 127}, {false, 120, 126}}, want: "{[120,127]}", total: 8},
+		{name: "touching at min", ops: []op8{{false, -128, -128}, {false, -127, -120}}, want: "{[-128,-120]}", total: 9},
+		{name: "not touching", ops: []op8{{false, -128, -127}, {false, 126, 127}}, want: "{[-128,-127] [126,127]}", total: 4},
+		{name: "everything", ops: []op8{{false, 0, 127}, {false, -128, -1}}, want: "{[-128,127]}", total: 127},
+		{name: "remove the ends", ops: []op8{{false, -128, 127}, {true, -128, -128}, {true, 127, 127}}, want: "{[-127,126]}", total: 127},
+		{name: "remove the middle", ops: []op8{{false, -128, 127}, {true, -1, 0}}, want: "{[-128,-2] [1,127]}", total: 127},
+		{name: "remove everything", ops: []op8{{false, -5, 5}, {false, 100, 127}, {true, -128, 127}}, want: "{}", total: 0},
+		{name: "empty range", ops: []op8{{false, 5, 4}}, want: "{}", total: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &IntervalSet[int8]{}
+			for _, op := range tt.ops {
+				if op.remove {
+					s.Remove(op.lo, op.hi)
+				} else {
+					s.Insert(op.lo, op.hi)
+				}
+			}
+			if got := s.String(); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+			if got := s.Total(); got != tt.total {
+				t.Errorf("Total() = %d, want %d", got, tt.total)
+			}
+		})
+	}
+}
+
+func TestIntervalSetComplementEdges(t *testing.T) {
+	tests := []struct {
+		name   string
+		set    []Interval[int8]
+		lo, hi int8
+		want   string
+	}{
+		{name: "of nothing", lo: math.MinInt8, hi: math.MaxInt8, want: "{[-128,127]}"},
+		{name: "of everything", set: []Interval[int8]{{math.MinInt8, math.MaxInt8}}, lo: math.MinInt8, hi: math.MaxInt8, want: "{}"},
+		{name: "of the ends", set: []Interval[int8]{{-128, -100}, {100, 127}}, lo: math.MinInt8, hi: math.MaxInt8, want: "{[-99,99]}"},
+		{name: "of the middle", set: []Interval[int8]{{-1, 1}}, lo: math.MinInt8, hi: math.MaxInt8, want: "{[-128,-2] [2,127]}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewIntervalSet(tt.set...).Complement(tt.lo, tt.hi).String(); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntervalLen(t *testing.T) {
+	tests := []struct {
+		iv   Interval[int8]
+		want int8
+	}{
+		{Interval[int8]{0, 0}, 1},
+		{Interval[int8]{5, 4}, 0},
+		{Interval[int8]{-128, -2}, 127},
+		{Interval[int8]{0, 126}, 127},
+		{Interval[int8]{-1, 126}, 127}, // 128, capped
+		{Interval[int8]{0, 127}, 127},  // 128, capped
+		{Interval[int8]{-128, 127}, 127},
+	}
+	for _, tt := range tests {
+		if got := tt.iv.Len(); got != tt.want {
+			t.Errorf("%v.Len() = %d, want %d", tt.iv, got, tt.want)
+		}
+	}
+	if got := (Interval[uint8]{0, 255}).Len(); got != 255 {
+		t.Errorf("[0,255].Len() = %d, want 255", got)
+	}
+}
+
+func TestIntervalSplit(t *testing.T) {
+	tests := []struct {
+		name string
+		iv   Interval[int8]
+		cuts []int8
+		want []Interval[int8]
+	}{
+		{name: "no cuts", iv: Interval[int8]{0, 10}, want: []Interval[int8]{{0, 10}}},
+		{name: "inside", iv: Interval[int8]{0, 10}, cuts: []int8{5, 3, 5}, want: []Interval[int8]{{0, 2}, {3, 4}, {5, 10}}},
+		{name: "at Lo is ignored", iv: Interval[int8]{0, 10}, cuts: []int8{0}, want: []Interval[int8]{{0, 10}}},
+		{name: "at Hi", iv: Interval[int8]{0, 10}, cuts: []int8{10}, want: []Interval[int8]{{0, 9}, {10, 10}}},
+		{name: "outside are ignored", iv: Interval[int8]{0, 10}, cuts: []int8{-5, 11, 127, -128}, want: []Interval[int8]{{0, 10}}},
+		{name: "at the ends of int8", iv: Interval[int8]{-128, 127}, cuts: []int8{-128, -127, 127}, want: []Interval[int8]{{-128, -128}, {-127, 126}, {127, 127}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.iv.Split(tt.cuts...); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
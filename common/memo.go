@@ -0,0 +1,184 @@
+package common
+
+import (
+	"container/list"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Memo caches a recursive function. The function gets a recurse func to call instead of itself so that inner calls
+// hit the cache too. Memos hold no global state: make one per solver run.
+type Memo[K comparable, V any] struct {
+	fn    func(recurse func(K) V, k K) V
+	cache *lruCache[K, V]
+	stats MemoStats
+}
+
+func NewMemo[K comparable, V any](fn func(recurse func(K) V, k K) V) *Memo[K, V] {
+	return NewLRUMemo(0, fn)
+}
+
+// NewLRUMemo is like NewMemo but keeps at most size results, evicting the least recently used. A size <= 0 means
+// unbounded.
+func NewLRUMemo[K comparable, V any](size int, fn func(recurse func(K) V, k K) V) *Memo[K, V] {
+	return &Memo[K, V]{fn: fn, cache: newLRUCache[K, V](size)}
+}
+
+func (m *Memo[K, V]) Get(k K) V {
+	if v, ok := m.cache.get(k); ok {
+		m.stats.Hits++
+		return v
+	}
+	m.stats.Misses++
+	v := m.fn(m.Get, k)
+	if m.cache.put(k, v) {
+		m.stats.Evictions++
+	}
+	return v
+}
+
+func (m *Memo[K, V]) Stats() MemoStats {
+	s := m.stats
+	s.Size = m.cache.len()
+	return s
+}
+
+// Reset drops every cached result and zeroes the stats.
+func (m *Memo[K, V]) Reset() {
+	m.cache = newLRUCache[K, V](m.cache.limit)
+	m.stats = MemoStats{}
+}
+
+// SyncMemo is a Memo that can be shared between goroutines. Concurrent calls for the same key wait for a single
+// computation rather than repeating it.
+type SyncMemo[K comparable, V any] struct {
+	fn func(recurse func(K) V, k K) V
+
+	mu      sync.Mutex
+	cache   *lruCache[K, V]
+	pending map[K]chan struct{}
+	stats   MemoStats
+}
+
+func NewSyncMemo[K comparable, V any](size int, fn func(recurse func(K) V, k K) V) *SyncMemo[K, V] {
+	return &SyncMemo[K, V]{fn: fn, cache: newLRUCache[K, V](size), pending: map[K]chan struct{}{}}
+}
+
+func (m *SyncMemo[K, V]) Get(k K) V {
+	for {
+		m.mu.Lock()
+		if v, ok := m.cache.get(k); ok {
+			m.stats.Hits++
+			m.mu.Unlock()
+			return v
+		}
+		wait, inFlight := m.pending[k]
+		if !inFlight {
+			break // we compute it, still holding the lock
+		}
+		m.mu.Unlock()
+		<-wait
+		// the result may already have been evicted again, so go round and check
+	}
+	done := make(chan struct{})
+	m.pending[k] = done
+	m.stats.Misses++
+	m.mu.Unlock()
+
+	v := m.fn(m.Get, k)
+
+	m.mu.Lock()
+	if m.cache.put(k, v) {
+		m.stats.Evictions++
+	}
+	delete(m.pending, k)
+	m.mu.Unlock()
+	close(done)
+	return v
+}
+
+func (m *SyncMemo[K, V]) Stats() MemoStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.stats
+	s.Size = m.cache.len()
+	return s
+}
+
+type MemoStats struct {
+	Hits, Misses, Evictions, Size int
+}
+
+func (s MemoStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+func (s MemoStats) String() string {
+	return fmt.Sprintf("hits=%d misses=%d evictions=%d size=%d hit_rate=%.3f",
+		s.Hits, s.Misses, s.Evictions, s.Size, s.HitRate())
+}
+
+func (s MemoStats) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("hits", s.Hits),
+		slog.Int("misses", s.Misses),
+		slog.Int("evictions", s.Evictions),
+		slog.Int("size", s.Size),
+		slog.Float64("hit_rate", s.HitRate()),
+	)
+}
+
+// lruCache is a map that, if limit > 0, evicts the least recently used entry once it's full
+type lruCache[K comparable, V any] struct {
+	limit int
+	items map[K]*list.Element
+	order *list.List // front is most recently used
+}
+
+type lruEntry[K comparable, V any] struct {
+	k K
+	v V
+}
+
+func newLRUCache[K comparable, V any](limit int) *lruCache[K, V] {
+	return &lruCache[K, V]{limit: limit, items: map[K]*list.Element{}, order: list.New()}
+}
+
+func (c *lruCache[K, V]) len() int {
+	return len(c.items)
+}
+
+func (c *lruCache[K, V]) get(k K) (V, bool) {
+	e, ok := c.items[k]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	if c.limit > 0 {
+		c.order.MoveToFront(e)
+	}
+	return e.Value.(*lruEntry[K, V]).v, true
+}
+
+// put stores v and reports whether something had to be evicted to make room
+func (c *lruCache[K, V]) put(k K, v V) bool {
+	if e, ok := c.items[k]; ok {
+		e.Value.(*lruEntry[K, V]).v = v
+		if c.limit > 0 {
+			c.order.MoveToFront(e)
+		}
+		return false
+	}
+	c.items[k] = c.order.PushFront(&lruEntry[K, V]{k, v})
+	if c.limit <= 0 || len(c.items) <= c.limit {
+		return false
+	}
+	oldest := c.order.Back()
+	c.order.Remove(oldest)
+	delete(c.items, oldest.Value.(*lruEntry[K, V]).k)
+	return true
+}
@@ -0,0 +1,103 @@
+package numth
+
+import (
+	"math/big"
+)
+
+// Int is an integer that stays a plain int until an operation would overflow, after which it's backed by a
+// big.Int. The zero value is 0.
+type Int struct {
+	small int
+	big   *big.Int // non-nil only when the value doesn't fit in an int
+}
+
+func I(v int) Int {
+	return Int{small: v}
+}
+
+func fromBig(b *big.Int) Int {
+	if b.IsInt64() {
+		return Int{small: int(b.Int64())}
+	}
+	return Int{big: b}
+}
+
+func (a Int) toBig() *big.Int {
+	if a.big != nil {
+		return a.big
+	}
+	return big.NewInt(int64(a.small))
+}
+
+// Small returns the value as an int, if it fits.
+func (a Int) Small() (int, bool) {
+	return a.small, a.big == nil
+}
+
+// Big returns the value as a new big.Int.
+func (a Int) Big() *big.Int {
+	return new(big.Int).Set(a.toBig())
+}
+
+func (a Int) Add(b Int) Int {
+	if a.big == nil && b.big == nil {
+		if s, ok := AddChecked(a.small, b.small); ok {
+			return I(s)
+		}
+	}
+	return fromBig(new(big.Int).Add(a.toBig(), b.toBig()))
+}
+
+func (a Int) Sub(b Int) Int {
+	return a.Add(b.Neg())
+}
+
+func (a Int) Neg() Int {
+	if a.big == nil && (a.small == 0 || a.small != -a.small) {
+		return I(-a.small)
+	}
+	return fromBig(new(big.Int).Neg(a.toBig()))
+}
+
+func (a Int) Mul(b Int) Int {
+	if a.big == nil && b.big == nil {
+		if p, ok := MulChecked(a.small, b.small); ok {
+			return I(p)
+		}
+	}
+	return fromBig(new(big.Int).Mul(a.toBig(), b.toBig()))
+}
+
+// Mod is the non-negative remainder of a / m. It panics if m is 0.
+func (a Int) Mod(m Int) Int {
+	if a.big == nil && m.big == nil {
+		return I(Mod(a.small, m.small))
+	}
+	r := new(big.Int).Mod(a.toBig(), m.toBig())
+	return fromBig(r)
+}
+
+func (a Int) Cmp(b Int) int {
+	if a.big == nil && b.big == nil {
+		switch {
+		case a.small < b.small:
+			return -1
+		case a.small > b.small:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return a.toBig().Cmp(b.toBig())
+}
+
+func (a Int) Sign() int {
+	if a.big != nil {
+		return a.big.Sign()
+	}
+	return int(Sign(a.small))
+}
+
+func (a Int) String() string {
+	return a.toBig().String()
+}
@@ -0,0 +1,272 @@
+package numth
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"math/bits"
+	"unsafe"
+)
+
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+type Signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+var (
+	ErrOverflow   = errors.New("integer overflow")
+	ErrNoInverse  = errors.New("no modular inverse")
+	ErrNoSolution = errors.New("no solution")
+)
+
+func Abs[T Signed](x T) T {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func Sign[T Signed](x T) T {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Min is the smallest of xs. It panics if xs is empty.
+func Min[T Integer](xs ...T) T {
+	m := xs[0]
+	for _, x := range xs[1:] {
+		m = min(m, x)
+	}
+	return m
+}
+
+// Max is the largest of xs. It panics if xs is empty.
+func Max[T Integer](xs ...T) T {
+	m := xs[0]
+	for _, x := range xs[1:] {
+		m = max(m, x)
+	}
+	return m
+}
+
+// GCD is the (non-negative) greatest common divisor of xs. GCD() is 0.
+func GCD[T Signed](xs ...T) T {
+	var g T
+	for _, x := range xs {
+		a, b := Abs(g), Abs(x)
+		for b != 0 {
+			a, b = b, a%b
+		}
+		g = a
+	}
+	return g
+}
+
+// LCM is the (non-negative) least common multiple of xs. LCM() is 1, and it's 0 if any of xs is. It fails with
+// ErrOverflow if the result doesn't fit in a T.
+func LCM[T Signed](xs ...T) (T, error) {
+	var l T = 1
+	for _, x := range xs {
+		if x == 0 {
+			return 0, nil
+		}
+		p, ok := MulChecked(l/GCD(l, x), x)
+		if ok && p < 0 {
+			p, ok = MulChecked(p, -1)
+		}
+		if !ok {
+			return 0, ErrOverflow
+		}
+		l = p
+	}
+	return l, nil
+}
+
+// ExtGCD returns g = gcd(a, b) along with x and y such that a*x + b*y = g.
+func ExtGCD[T Signed](a, b T) (g, x, y T) {
+	oldR, r := a, b
+	oldS, s := T(1), T(0)
+	oldT, t := T(0), T(1)
+	for r != 0 {
+		q := oldR / r
+		oldR, r = r, oldR-q*r
+		oldS, s = s, oldS-q*s
+		oldT, t = t, oldT-q*t
+	}
+	if oldR < 0 {
+		oldR, oldS, oldT = -oldR, -oldS, -oldT
+	}
+	return oldR, oldS, oldT
+}
+
+// Mod is the mathematical modulus: always in [0, m) for m > 0.
+func Mod[T Signed](a, m T) T {
+	r := a % m
+	if r < 0 {
+		r += Abs(m)
+	}
+	return r
+}
+
+// ModInverse returns x in [0, m) with a*x = 1 (mod m).
+func ModInverse(a, m int) (int, error) {
+	g, x, _ := ExtGCD(Mod(a, m), m)
+	if g != 1 {
+		return 0, ErrNoInverse
+	}
+	return Mod(x, m), nil
+}
+
+// MulMod is a*b mod m without overflowing, for m > 0.
+func MulMod(a, b, m int) int {
+	a, b = Mod(a, m), Mod(b, m)
+	hi, lo := bits.Mul64(uint64(a), uint64(b))
+	_, rem := bits.Div64(hi%uint64(m), lo, uint64(m))
+	return int(rem)
+}
+
+// ModPow is base^exp mod m, for exp >= 0 and m > 0.
+func ModPow(base, exp, m int) int {
+	result := 1 % m
+	base = Mod(base, m)
+	for ; exp > 0; exp >>= 1 {
+		if exp&1 == 1 {
+			result = MulMod(result, base, m)
+		}
+		base = MulMod(base, base, m)
+	}
+	return result
+}
+
+// MaxOf is the largest value a T can hold.
+func MaxOf[T Integer]() T {
+	x := ^T(0)
+	if x > 0 {
+		return x // unsigned: all ones
+	}
+	// signed: all ones but the sign bit
+	return ^(T(1) << (8*unsafe.Sizeof(x) - 1))
+}
+
+// AddChecked is a+b, with ok false if that overflows a T.
+func AddChecked[T Signed](a, b T) (T, bool) {
+	s := a + b
+	if (a > 0 && b > 0 && s < 0) || (a < 0 && b < 0 && s >= 0) {
+		return 0, false
+	}
+	return s, true
+}
+
+// MulChecked is a*b, with ok false if that overflows a T.
+func MulChecked[T Signed](a, b T) (T, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	lowest := -MaxOf[T]() - 1
+	if (a == -1 && b == lowest) || (b == -1 && a == lowest) {
+		return 0, false
+	}
+	p := a * b
+	if p/b != a {
+		return 0, false
+	}
+	return p, true
+}
+
+// ISqrt is floor(sqrt(n)), for n >= 0.
+func ISqrt(n int) int {
+	if n < 0 {
+		panic("ISqrt of negative number")
+	}
+	r := int(math.Sqrt(float64(n)))
+	// the float estimate can be off by one either way for large n
+	for r*r > n {
+		r--
+	}
+	for (r+1)*(r+1) <= n && (r+1)*(r+1) > 0 {
+		r++
+	}
+	return r
+}
+
+// CRT solves x = residues[i] (mod moduli[i]) for all i, with moduli that needn't be coprime. It returns the
+// smallest non-negative x and the combined modulus (the lcm of moduli). If the result doesn't fit in an int, it's
+// computed with math/big instead.
+func CRT(residues, moduli []int) (x, m Int, err error) {
+	if len(residues) != len(moduli) {
+		return Int{}, Int{}, errors.New("residues and moduli differ in length")
+	}
+	// checked up front, since crtBig takes over partway through if crtSmall overflows
+	for _, mi := range moduli {
+		if mi <= 0 {
+			return Int{}, Int{}, errors.New("moduli must be positive")
+		}
+	}
+	sx, sm, err := crtSmall(residues, moduli)
+	if err == nil {
+		return I(sx), I(sm), nil
+	}
+	if !errors.Is(err, ErrOverflow) {
+		return Int{}, Int{}, err
+	}
+	bx, bm, err := crtBig(residues, moduli)
+	if err != nil {
+		return Int{}, Int{}, err
+	}
+	return fromBig(bx), fromBig(bm), nil
+}
+
+func crtSmall(residues, moduli []int) (int, int, error) {
+	x, m := 0, 1
+	for i, mi := range moduli {
+		ri := Mod(residues[i], mi)
+		g, p, _ := ExtGCD(m, mi)
+		if (ri-x)%g != 0 {
+			return 0, 0, ErrNoSolution
+		}
+		lcm, ok := MulChecked(m/g, mi)
+		if !ok {
+			return 0, 0, ErrOverflow
+		}
+		// x += m * ((ri-x)/g * p mod mi/g)
+		step := MulMod((ri-x)/g, p, mi/g)
+		inc, ok := MulChecked(m, step)
+		if !ok {
+			return 0, 0, ErrOverflow
+		}
+		if x, ok = AddChecked(x, inc); !ok {
+			return 0, 0, ErrOverflow
+		}
+		x, m = Mod(x, lcm), lcm
+	}
+	return x, m, nil
+}
+
+func crtBig(residues, moduli []int) (*big.Int, *big.Int, error) {
+	x, m := big.NewInt(0), big.NewInt(1)
+	g, p, tmp := new(big.Int), new(big.Int), new(big.Int)
+	for i, mi := range moduli {
+		bmi := big.NewInt(int64(mi))
+		ri := new(big.Int).Mod(big.NewInt(int64(residues[i])), bmi)
+		g.GCD(p, nil, m, bmi)
+		diff := new(big.Int).Sub(ri, x)
+		if tmp.Mod(diff, g).Sign() != 0 {
+			return nil, nil, ErrNoSolution
+		}
+		mig := new(big.Int).Quo(bmi, g)
+		diff.Quo(diff, g).Mul(diff, p).Mod(diff, mig)
+		x.Add(x, diff.Mul(diff, m))
+		m.Mul(m, mig)
+		x.Mod(x, m)
+	}
+	return x, m, nil
+}
@@ -0,0 +1,68 @@
+package numth
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestCRT(t *testing.T) {
+	tests := []struct {
+		name             string
+		residues, moduli []int
+		wantX, wantM     string
+		wantErr          bool
+	}{
+		{name: "coprime", residues: []int{2, 3, 2}, moduli: []int{3, 5, 7}, wantX: "23", wantM: "105"},
+		{name: "not coprime", residues: []int{1, 3}, moduli: []int{4, 6}, wantX: "9", wantM: "12"},
+		{name: "no solution", residues: []int{1, 2}, moduli: []int{4, 6}, wantErr: true},
+		{name: "overflows into big", residues: []int{1, 2}, moduli: []int{1 << 40, 1<<40 + 1}, wantX: "1208925819614629174706177", wantM: "1208925819615728686333952"},
+		{name: "zero modulus", residues: []int{1, 1}, moduli: []int{5, 0}, wantErr: true},
+		// crtSmall overflows on the second pair before it gets to the zero
+		{name: "zero modulus after overflow", residues: []int{1, 1, 1}, moduli: []int{1 << 40, 1<<40 + 1, 0}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, m, err := CRT(tt.residues, tt.moduli)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("CRT = %v, %v; want an error", x, m)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if x.String() != tt.wantX || m.String() != tt.wantM {
+				t.Errorf("CRT = %v, %v; want %s, %s", x, m, tt.wantX, tt.wantM)
+			}
+		})
+	}
+}
+
+func TestLCM(t *testing.T) {
+	if l, err := LCM(4, -6, 10); l != 60 || err != nil {
+		t.Errorf("LCM(4, -6, 10) = %d, %v; want 60", l, err)
+	}
+	if l, err := LCM(3, 0); l != 0 || err != nil {
+		t.Errorf("LCM(3, 0) = %d, %v; want 0", l, err)
+	}
+	if l, err := LCM[int8](16, 9); !errors.Is(err, ErrOverflow) {
+		t.Errorf("LCM[int8](16, 9) = %d, %v; want ErrOverflow", l, err)
+	}
+	if l, err := LCM(math.MinInt); !errors.Is(err, ErrOverflow) {
+		t.Errorf("LCM(MinInt) = %d, %v; want ErrOverflow", l, err)
+	}
+}
+
+func TestMulChecked(t *testing.T) {
+	for a := math.MinInt8; a <= math.MaxInt8; a++ {
+		for b := math.MinInt8; b <= math.MaxInt8; b++ {
+			p, ok := MulChecked(int8(a), int8(b))
+			fits := a*b >= math.MinInt8 && a*b <= math.MaxInt8
+			if ok != fits || ok && int(p) != a*b {
+				t.Fatalf("MulChecked(%d, %d) = %d, %t", a, b, p, ok)
+			}
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Error is a parse failure at a (1-based) line and column. A zero Line or Col means unknown.
+type Error struct {
+	Line, Col int
+	Err       error
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Line > 0 && e.Col > 0:
+		return fmt.Sprintf("line %d, col %d: %v", e.Line, e.Col, e.Err)
+	case e.Line > 0:
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	case e.Col > 0:
+		return fmt.Sprintf("col %d: %v", e.Col, e.Err)
+	default:
+		return e.Err.Error()
+	}
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+var ErrNoMatch = errors.New("line does not match pattern")
+
+// AtLine attaches a line number to err, keeping any column it already carries.
+func AtLine(line int, err error) error {
+	if err == nil {
+		return nil
+	}
+	var pe *Error
+	if errors.As(err, &pe) {
+		return &Error{Line: line, Col: pe.Col, Err: pe.Err}
+	}
+	return &Error{Line: line, Err: err}
+}
+
+func atCol(col int, err error) error {
+	return &Error{Col: col, Err: err}
+}
+
+// Lines splits s into lines, dropping a trailing newline and any \r line endings.
+func Lines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSuffix(l, "\r")
+	}
+	return lines
+}
+
+var intsRx = regexp.MustCompile(`[-+]?[0-9]+`)
+
+// Ints extracts every (optionally signed) integer in s, ignoring whatever is around them.
+func Ints(s string) ([]int, error) {
+	locs := intsRx.FindAllStringIndex(s, -1)
+	ints := make([]int, 0, len(locs))
+	for _, loc := range locs {
+		// a sign glued to the previous number is a separator, not a sign (eg "1-2")
+		start := loc[0]
+		if (s[start] == '-' || s[start] == '+') && start > 0 && s[start-1] >= '0' && s[start-1] <= '9' {
+			start++
+		}
+		v, err := strconv.Atoi(s[start:loc[1]])
+		if err != nil {
+			return nil, atCol(start+1, err)
+		}
+		ints = append(ints, v)
+	}
+	return ints, nil
+}
+
+// IntList parses s as a list of integers separated by sep. An empty sep splits on whitespace.
+func IntList(s, sep string) ([]int, error) {
+	var ints []int
+	for col, field := range fields(s, sep) {
+		v, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, atCol(col, fmt.Errorf("invalid integer %q", field))
+		}
+		ints = append(ints, v)
+	}
+	return ints, nil
+}
+
+// fields is like strings.Split / strings.Fields, but keyed by the 1-based column each field starts at.
+func fields(s, sep string) iter.Seq2[int, string] {
+	return func(yield func(int, string) bool) {
+		if sep == "" {
+			start := -1
+			for i, r := range s {
+				if unicode.IsSpace(r) {
+					if start >= 0 && !yield(start+1, s[start:i]) {
+						return
+					}
+					start = -1
+				} else if start < 0 {
+					start = i
+				}
+			}
+			if start >= 0 {
+				yield(start+1, s[start:])
+			}
+			return
+		}
+		col := 1
+		for {
+			i := strings.Index(s, sep)
+			if i < 0 {
+				yield(col, s)
+				return
+			}
+			if !yield(col, s[:i]) {
+				return
+			}
+			s = s[i+len(sep):]
+			col += i + len(sep)
+		}
+	}
+}
+
+// Columns parses whitespace-separated integer columns into n parallel slices. Blank lines are skipped.
+func Columns(s string, n int) ([][]int, error) {
+	cols := make([][]int, n)
+	for i, line := range Lines(s) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		row, err := Row(line, n)
+		if err != nil {
+			return nil, AtLine(i+1, err)
+		}
+		for c, v := range row {
+			cols[c] = append(cols[c], v)
+		}
+	}
+	return cols, nil
+}
+
+// Row parses a single line of exactly n whitespace-separated integers.
+func Row(line string, n int) ([]int, error) {
+	row, err := IntList(line, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(row) != n {
+		return nil, fmt.Errorf("expected %d columns, got %d", n, len(row))
+	}
+	return row, nil
+}
+
+// Decoder fills a struct from a regex's named capture groups. Fields opt in with a `parse:"group"` tag.
+type Decoder[T any] struct {
+	rx     *regexp.Regexp
+	fields []decodeField
+}
+
+type decodeField struct {
+	index []int
+	group int
+	name  string
+}
+
+func NewDecoder[T any](pattern string) (*Decoder[T], error) {
+	rx, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("decoder target must be a struct, got %s", typ)
+	}
+
+	d := &Decoder[T]{rx: rx}
+	for _, f := range reflect.VisibleFields(typ) {
+		name, ok := f.Tag.Lookup("parse")
+		if !ok {
+			continue
+		}
+		if !f.IsExported() {
+			return nil, fmt.Errorf("field %s is tagged but unexported", f.Name)
+		}
+		if !settable(f.Type.Kind()) {
+			return nil, fmt.Errorf("field %s has unsupported type %s", f.Name, f.Type)
+		}
+		group := rx.SubexpIndex(name)
+		if group < 0 {
+			return nil, fmt.Errorf("field %s: no capture group named %q in %s", f.Name, name, pattern)
+		}
+		d.fields = append(d.fields, decodeField{index: f.Index, group: group, name: name})
+	}
+	return d, nil
+}
+
+func MustDecoder[T any](pattern string) *Decoder[T] {
+	d, err := NewDecoder[T](pattern)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// Decode matches s against the pattern and converts each tagged capture into its field.
+func (d *Decoder[T]) Decode(s string) (T, error) {
+	var out T
+	m := d.rx.FindStringSubmatchIndex(s)
+	if m == nil {
+		return out, atCol(1, ErrNoMatch)
+	}
+	v := reflect.ValueOf(&out).Elem()
+	for _, f := range d.fields {
+		start, end := m[2*f.group], m[2*f.group+1]
+		if start < 0 {
+			continue // optional group didn't participate
+		}
+		if err := SetField(v.FieldByIndex(f.index), s[start:end]); err != nil {
+			return out, atCol(start+1, fmt.Errorf("group %s: %w", f.name, err))
+		}
+	}
+	return out, nil
+}
+
+func settable(k reflect.Kind) bool {
+	switch k {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// SetField parses s into v according to v's kind: a string, bool, int, uint or float of any size.
+func SetField(v reflect.Value, s string) error {
+	if !settable(v.Kind()) {
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	}
+	return nil
+}
@@ -0,0 +1,409 @@
+package pattern
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.coldcutz.net/advent2024/common"
+)
+
+// Orientations selects which transformations of a pattern are tried.
+type Orientations int
+
+const (
+	// Fixed tries the pattern only as written.
+	Fixed Orientations = iota
+	// Rotations tries the four quarter turns.
+	Rotations
+	// All tries the four quarter turns of the pattern and of its mirror image.
+	All
+)
+
+// Orientation is a transformation of a pattern: Reflected (mirrored left-right) first, then rotated clockwise by
+// Rotation quarter turns.
+type Orientation struct {
+	Rotation  int
+	Reflected bool
+}
+
+func (o Orientation) String() string {
+	s := fmt.Sprintf("rot%d", o.Rotation*90)
+	if o.Reflected {
+		s = "mirror+" + s
+	}
+	return s
+}
+
+type cell struct {
+	at      common.Point
+	any     bool
+	lit     rune
+	class   []classRange
+	negated bool
+	v       int // variable index, or -1
+}
+
+type classRange struct {
+	lo, hi rune
+}
+
+func (c cell) accepts(r rune) bool {
+	switch {
+	case c.any:
+		return true
+	case c.class != nil:
+		in := slices.ContainsFunc(c.class, func(cr classRange) bool { return cr.lo <= r && r <= cr.hi })
+		return in != c.negated
+	default:
+		return r == c.lit
+	}
+}
+
+func (c cell) isLiteral() bool {
+	return !c.any && c.class == nil && c.v < 0
+}
+
+func (c cell) key() string {
+	return fmt.Sprintf("%d,%d:%t:%q:%v:%t:%d", c.at.X, c.at.Y, c.any, c.lit, c.class, c.negated, c.v)
+}
+
+type constraint struct {
+	a, b  int
+	equal bool
+}
+
+// Variant is one orientation of a pattern, normalized so its bounding box starts at (0, 0).
+type Variant struct {
+	Orientation Orientation
+	Width       int
+	Height      int
+	cells       []cell
+}
+
+// Pattern is a compiled spec with all of its distinct orientations.
+type Pattern struct {
+	Variants    []Variant
+	vars        []string
+	constraints []constraint
+}
+
+// Match is a place the pattern matched: the top-left corner of the matching variant's bounding box, which
+// orientation it was, the grid cells it covered and what each variable was bound to. Literals is the subset of
+// Cells matched by a literal character, as opposed to a wildcard, class or variable.
+type Match struct {
+	At          common.Point
+	Orientation Orientation
+	Cells       []common.Point
+	Literals    []common.Point
+	Vars        map[string]rune
+}
+
+// Compile parses spec and generates its variants for the given orientations, dropping any that are identical to
+// an earlier one (eg the rotations of a symmetric pattern).
+//
+// A pattern spec is a block of rows, one token per cell:
+//
+//	X        a literal character (escape specials with a backslash, eg `\.`)
+//	.        any character
+//	(space)  not part of the pattern; may even fall outside the grid
+//	[abc]    one of a, b or c; ranges like [a-z] work, and [^...] negates
+//	{v}      any character, bound to variable v; every cell bound to v must be the same character
+//	{v:[..]} a variable restricted to a class
+//
+// optionally followed by a line of just `--` and then one constraint per line between variables: `a != b` or
+// `a == b`. Blank lines before and after the rows are ignored.
+//
+// For example, an X of two MAS diagonals:
+//
+//	{a:[MS]}.{b:[MS]}
+//	.A.
+//	{c:[MS]}.{d:[MS]}
+//	--
+//	a != d
+//	b != c
+func Compile(spec string, orient Orientations) (*Pattern, error) {
+	p := &Pattern{}
+	cells, err := p.parse(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, reflected := range []bool{false, true} {
+		if reflected && orient != All {
+			break
+		}
+		for rot := range 4 {
+			if rot > 0 && orient == Fixed {
+				break
+			}
+			v := transform(cells, Orientation{rot, reflected})
+			key := v.key()
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			p.Variants = append(p.Variants, v)
+		}
+	}
+	return p, nil
+}
+
+func MustCompile(spec string, orient Orientations) *Pattern {
+	p, err := Compile(spec, orient)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func (p *Pattern) parse(spec string) ([]cell, error) {
+	lines := strings.Split(strings.ReplaceAll(spec, "\r\n", "\n"), "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	rows, rules := lines, []string(nil)
+	if i := slices.Index(lines, "--"); i >= 0 {
+		rows, rules = lines[:i], lines[i+1:]
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("empty pattern")
+	}
+
+	var cells []cell
+	for y, row := range rows {
+		x := 0
+		rs := []rune(row)
+		for i := 0; i < len(rs); x++ {
+			c, n, err := p.parseCell(rs[i:])
+			if err != nil {
+				return nil, fmt.Errorf("row %d, cell %d: %w", y+1, x+1, err)
+			}
+			i += n
+			if c == nil {
+				continue
+			}
+			c.at = common.Point{X: x, Y: y}
+			cells = append(cells, *c)
+		}
+	}
+	if len(cells) == 0 {
+		return nil, fmt.Errorf("pattern has no cells")
+	}
+
+	for _, rule := range rules {
+		if strings.TrimSpace(rule) == "" {
+			continue
+		}
+		fields := strings.Fields(rule)
+		if len(fields) != 3 || (fields[1] != "!=" && fields[1] != "==") {
+			return nil, fmt.Errorf("invalid constraint %q", rule)
+		}
+		a, b := slices.Index(p.vars, fields[0]), slices.Index(p.vars, fields[2])
+		if a < 0 || b < 0 {
+			return nil, fmt.Errorf("constraint %q uses an undefined variable", rule)
+		}
+		p.constraints = append(p.constraints, constraint{a, b, fields[1] == "=="})
+	}
+	return cells, nil
+}
+
+// parseCell reads one cell token from the start of rs, returning the cell (nil for a skipped one) and the number
+// of runes it took up
+func (p *Pattern) parseCell(rs []rune) (*cell, int, error) {
+	switch rs[0] {
+	case ' ':
+		return nil, 1, nil
+	case '.':
+		return &cell{any: true, v: -1}, 1, nil
+	case '\\':
+		if len(rs) < 2 {
+			return nil, 0, fmt.Errorf("dangling escape")
+		}
+		return &cell{lit: rs[1], v: -1}, 2, nil
+	case '[':
+		c, n, err := parseClass(rs)
+		if err != nil {
+			return nil, 0, err
+		}
+		c.v = -1
+		return c, n, nil
+	case '{':
+		end := slices.Index(rs, '}')
+		if end < 0 {
+			return nil, 0, fmt.Errorf("unterminated variable")
+		}
+		name, class, hasClass := strings.Cut(string(rs[1:end]), ":")
+		if name == "" {
+			return nil, 0, fmt.Errorf("empty variable name")
+		}
+		c := &cell{any: true}
+		if hasClass {
+			var err error
+			var n int
+			c, n, err = parseClass([]rune(class))
+			if err != nil {
+				return nil, 0, err
+			}
+			if n != len([]rune(class)) {
+				return nil, 0, fmt.Errorf("junk after class in variable %s", name)
+			}
+		}
+		c.v = slices.Index(p.vars, name)
+		if c.v < 0 {
+			c.v = len(p.vars)
+			p.vars = append(p.vars, name)
+		}
+		return c, end + 1, nil
+	default:
+		return &cell{lit: rs[0], v: -1}, 1, nil
+	}
+}
+
+func parseClass(rs []rune) (*cell, int, error) {
+	if len(rs) == 0 || rs[0] != '[' {
+		return nil, 0, fmt.Errorf("expected a class")
+	}
+	c := &cell{class: []classRange{}}
+	i := 1
+	if i < len(rs) && rs[i] == '^' {
+		c.negated = true
+		i++
+	}
+	for ; i < len(rs) && rs[i] != ']'; i++ {
+		r := rs[i]
+		if r == '\\' && i+1 < len(rs) {
+			i++
+			r = rs[i]
+		}
+		if i+2 < len(rs) && rs[i+1] == '-' && rs[i+2] != ']' {
+			c.class = append(c.class, classRange{r, rs[i+2]})
+			i += 2
+			continue
+		}
+		c.class = append(c.class, classRange{r, r})
+	}
+	if i >= len(rs) {
+		return nil, 0, fmt.Errorf("unterminated class")
+	}
+	return c, i + 1, nil
+}
+
+func transform(cells []cell, o Orientation) Variant {
+	out := make([]cell, len(cells))
+	for i, c := range cells {
+		x, y := c.at.X, c.at.Y
+		if o.Reflected {
+			x = -x
+		}
+		for range o.Rotation {
+			x, y = -y, x
+		}
+		c.at = common.Point{X: x, Y: y}
+		out[i] = c
+	}
+
+	minX, minY := out[0].at.X, out[0].at.Y
+	maxX, maxY := minX, minY
+	for _, c := range out {
+		minX, minY = min(minX, c.at.X), min(minY, c.at.Y)
+		maxX, maxY = max(maxX, c.at.X), max(maxY, c.at.Y)
+	}
+	for i := range out {
+		out[i].at = common.Point{X: out[i].at.X - minX, Y: out[i].at.Y - minY}
+	}
+	slices.SortFunc(out, func(a, b cell) int {
+		if a.at.Y != b.at.Y {
+			return a.at.Y - b.at.Y
+		}
+		return a.at.X - b.at.X
+	})
+	return Variant{Orientation: o, Width: maxX - minX + 1, Height: maxY - minY + 1, cells: out}
+}
+
+func (v Variant) key() string {
+	keys := make([]string, len(v.cells))
+	for i, c := range v.cells {
+		keys[i] = c.key()
+	}
+	return strings.Join(keys, ";")
+}
+
+// matchAt reports whether v matches with its top-left corner at p, filling in bindings
+func matchAt[G ~[][]T, T ~rune](p *Pattern, v Variant, g G, at common.Point, bindings []rune, bound []bool) bool {
+	clear(bound)
+	for _, c := range v.cells {
+		q := at.Add(c.at)
+		if !common.InGrid(g, q) {
+			return false
+		}
+		r := rune(g[q.Y][q.X])
+		if !c.accepts(r) {
+			return false
+		}
+		if c.v >= 0 {
+			if bound[c.v] && bindings[c.v] != r {
+				return false
+			}
+			bindings[c.v], bound[c.v] = r, true
+		}
+	}
+	for _, con := range p.constraints {
+		if (bindings[con.a] == bindings[con.b]) != con.equal {
+			return false
+		}
+	}
+	return true
+}
+
+// FindAll returns every match of every variant in g, ordered by position and then by variant. Variants that
+// match in the same place are each reported, even if they cover the same cells; a pattern that's symmetric under
+// some orientation still reports each occurrence once, since Compile already dropped its duplicate variants.
+func FindAll[G ~[][]T, T ~rune](p *Pattern, g G) []Match {
+	var matches []Match
+	bindings, bound := make([]rune, len(p.vars)), make([]bool, len(p.vars))
+	for y, row := range g {
+		for x := range row {
+			at := common.Point{X: x, Y: y}
+			for _, v := range p.Variants {
+				if !matchAt(p, v, g, at, bindings, bound) {
+					continue
+				}
+				m := Match{At: at, Orientation: v.Orientation, Cells: v.Cells(at), Literals: v.literals(at)}
+				if len(p.vars) > 0 {
+					m.Vars = make(map[string]rune, len(p.vars))
+					for i, name := range p.vars {
+						m.Vars[name] = bindings[i]
+					}
+				}
+				matches = append(matches, m)
+			}
+		}
+	}
+	return matches
+}
+
+// Cells returns the grid positions the variant covers when matched with its top-left corner at at.
+func (v Variant) Cells(at common.Point) []common.Point {
+	ps := make([]common.Point, len(v.cells))
+	for i, c := range v.cells {
+		ps[i] = at.Add(c.at)
+	}
+	return ps
+}
+
+// literals is like Cells, but only the positions of literal cells
+func (v Variant) literals(at common.Point) []common.Point {
+	var ps []common.Point
+	for _, c := range v.cells {
+		if c.isLiteral() {
+			ps = append(ps, at.Add(c.at))
+		}
+	}
+	return ps
+}
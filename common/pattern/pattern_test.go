@@ -0,0 +1,64 @@
+package pattern
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"go.coldcutz.net/advent2024/common"
+)
+
+func grid(s string) [][]rune {
+	var g [][]rune
+	for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		g = append(g, []rune(line))
+	}
+	return g
+}
+
+func TestFindAllSameCells(t *testing.T) {
+	// rot0 and rot180 both cover (0,0) and (1,0), but with the A at opposite ends
+	p := MustCompile("A.", Rotations)
+	ms := FindAll(p, grid("AA"))
+
+	var got []Orientation
+	for _, m := range ms {
+		got = append(got, m.Orientation)
+	}
+	want := []Orientation{{Rotation: 0}, {Rotation: 2}}
+	if !slices.Equal(got, want) {
+		t.Fatalf("orientations = %v, want %v", got, want)
+	}
+	if lits := ms[1].Literals; !slices.Equal(lits, []common.Point{{X: 1, Y: 0}}) {
+		t.Errorf("rot180 literals = %v, want [(1,0)]", lits)
+	}
+}
+
+func TestFindAllXMAS(t *testing.T) {
+	g := grid(`
+.M.S......
+..A..MSMS.
+.M.S.MAA..
+..A.ASMSM.
+.M.S.M....
+..........
+S.S.S.S.S.
+.A.A.A.A..
+M.M.M.M.M.
+..........
+`)
+	p := MustCompile(`
+M.S
+.A.
+M.S
+`, Rotations)
+	ms := FindAll(p, g)
+	if len(ms) != 9 {
+		t.Errorf("got %d X-MASes, want 9", len(ms))
+	}
+	for _, m := range ms {
+		if len(m.Cells) != 9 || len(m.Literals) != 5 {
+			t.Errorf("match at %v covers %d cells with %d literals, want 9 and 5", m.At, len(m.Cells), len(m.Literals))
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package pc
+
+import (
+	"fmt"
+	"io"
+	"iter"
+
+	"go.coldcutz.net/advent2024/common"
+	"go.coldcutz.net/advent2024/common/parse"
+)
+
+// Parser tries to parse in starting at pos. On success it returns the value and the offset just past what it
+// consumed; on failure it returns the offset where it gave up, which is used for error positions.
+type Parser[T any] func(in []byte, pos int) (v T, next int, ok bool)
+
+// Span is a parsed value along with the [Start, End) byte range it came from.
+type Span[T any] struct {
+	Value      T
+	Start, End int
+}
+
+// Lit matches s exactly.
+func Lit(s string) Parser[string] {
+	return func(in []byte, pos int) (string, int, bool) {
+		for i := 0; i < len(s); i++ {
+			if pos+i >= len(in) || in[pos+i] != s[i] {
+				return "", pos + i, false
+			}
+		}
+		return s, pos + len(s), true
+	}
+}
+
+// Digits matches a run of between minDigits and maxDigits ASCII digits and returns it as an int. A maxDigits <= 0
+// means no limit. It doesn't backtrack: a longer run of digits than allowed is a failure, not a shorter match.
+func Digits(minDigits, maxDigits int) Parser[int] {
+	return func(in []byte, pos int) (int, int, bool) {
+		v, i := 0, pos
+		for ; i < len(in) && in[i] >= '0' && in[i] <= '9'; i++ {
+			if maxDigits > 0 && i-pos == maxDigits {
+				return 0, i, false
+			}
+			d := int(in[i] - '0')
+			if v > (maxInt-d)/10 {
+				return 0, i, false // overflow
+			}
+			v = v*10 + d
+		}
+		if i-pos < max(minDigits, 1) {
+			return 0, i, false
+		}
+		return v, i, true
+	}
+}
+
+const maxInt = int(^uint(0) >> 1)
+
+// Int matches an unsigned decimal integer of any length.
+func Int() Parser[int] {
+	return Digits(1, 0)
+}
+
+// Byte matches a single byte satisfying pred.
+func Byte(pred func(byte) bool) Parser[byte] {
+	return func(in []byte, pos int) (byte, int, bool) {
+		if pos >= len(in) || !pred(in[pos]) {
+			return 0, pos, false
+		}
+		return in[pos], pos + 1, true
+	}
+}
+
+func Map[A, B any](p Parser[A], f func(A) B) Parser[B] {
+	return func(in []byte, pos int) (B, int, bool) {
+		a, next, ok := p(in, pos)
+		if !ok {
+			var zero B
+			return zero, next, false
+		}
+		return f(a), next, true
+	}
+}
+
+// Where fails wherever p succeeds with a value that doesn't satisfy pred.
+func Where[T any](p Parser[T], pred func(T) bool) Parser[T] {
+	return func(in []byte, pos int) (T, int, bool) {
+		v, next, ok := p(in, pos)
+		if ok && !pred(v) {
+			var zero T
+			return zero, pos, false
+		}
+		return v, next, ok
+	}
+}
+
+func Seq2[A, B, R any](pa Parser[A], pb Parser[B], f func(A, B) R) Parser[R] {
+	return func(in []byte, pos int) (R, int, bool) {
+		var zero R
+		a, pos, ok := pa(in, pos)
+		if !ok {
+			return zero, pos, false
+		}
+		b, pos, ok := pb(in, pos)
+		if !ok {
+			return zero, pos, false
+		}
+		return f(a, b), pos, true
+	}
+}
+
+func Seq3[A, B, C, R any](pa Parser[A], pb Parser[B], pc Parser[C], f func(A, B, C) R) Parser[R] {
+	ab := Seq2(pa, pb, func(a A, b B) pair[A, B] { return pair[A, B]{a, b} })
+	return Seq2(ab, pc, func(ab pair[A, B], c C) R { return f(ab.a, ab.b, c) })
+}
+
+type pair[A, B any] struct {
+	a A
+	b B
+}
+
+// Left runs a then b and keeps a's value.
+func Left[A, B any](pa Parser[A], pb Parser[B]) Parser[A] {
+	return Seq2(pa, pb, func(a A, _ B) A { return a })
+}
+
+// Right runs a then b and keeps b's value.
+func Right[A, B any](pa Parser[A], pb Parser[B]) Parser[B] {
+	return Seq2(pa, pb, func(_ A, b B) B { return b })
+}
+
+// Between runs open, p, close and keeps p's value.
+func Between[O, T, C any](open Parser[O], p Parser[T], close Parser[C]) Parser[T] {
+	return Left(Right(open, p), close)
+}
+
+// Or returns the first alternative that succeeds. On failure it reports the furthest any alternative got.
+func Or[T any](ps ...Parser[T]) Parser[T] {
+	return func(in []byte, pos int) (T, int, bool) {
+		furthest := pos
+		for _, p := range ps {
+			v, next, ok := p(in, pos)
+			if ok {
+				return v, next, true
+			}
+			furthest = max(furthest, next)
+		}
+		var zero T
+		return zero, furthest, false
+	}
+}
+
+// Many matches p zero or more times.
+func Many[T any](p Parser[T]) Parser[[]T] {
+	return func(in []byte, pos int) ([]T, int, bool) {
+		var vs []T
+		for {
+			v, next, ok := p(in, pos)
+			if !ok || next == pos {
+				return vs, pos, true
+			}
+			vs = append(vs, v)
+			pos = next
+		}
+	}
+}
+
+// Optional matches p or nothing, in which case it yields def.
+func Optional[T any](p Parser[T], def T) Parser[T] {
+	return func(in []byte, pos int) (T, int, bool) {
+		v, next, ok := p(in, pos)
+		if !ok {
+			return def, pos, true
+		}
+		return v, next, true
+	}
+}
+
+// SepBy matches zero or more p separated by sep.
+func SepBy[T, S any](p Parser[T], sep Parser[S]) Parser[[]T] {
+	return func(in []byte, pos int) ([]T, int, bool) {
+		first, next, ok := p(in, pos)
+		if !ok {
+			return nil, pos, true
+		}
+		vs := []T{first}
+		pos = next
+		for {
+			_, afterSep, ok := sep(in, pos)
+			if !ok {
+				return vs, pos, true
+			}
+			v, next, ok := p(in, afterSep)
+			if !ok {
+				return vs, pos, true
+			}
+			vs = append(vs, v)
+			pos = next
+		}
+	}
+}
+
+// Spanned records where p's match starts and ends.
+func Spanned[T any](p Parser[T]) Parser[Span[T]] {
+	return func(in []byte, pos int) (Span[T], int, bool) {
+		v, next, ok := p(in, pos)
+		if !ok {
+			return Span[T]{}, next, false
+		}
+		return Span[T]{Value: v, Start: pos, End: next}, next, true
+	}
+}
+
+// Parse runs p over all of in. The error carries the (1-based) column parsing failed at.
+func Parse[T any](p Parser[T], in []byte) (T, error) {
+	v, next, ok := p(in, 0)
+	if !ok {
+		var zero T
+		return zero, &parse.Error{Col: next + 1, Err: unexpected(in, next)}
+	}
+	if next != len(in) {
+		var zero T
+		return zero, &parse.Error{Col: next + 1, Err: fmt.Errorf("trailing input: %w", unexpected(in, next))}
+	}
+	return v, nil
+}
+
+func unexpected(in []byte, pos int) error {
+	if pos >= len(in) {
+		return io.ErrUnexpectedEOF
+	}
+	return fmt.Errorf("unexpected %q", in[pos:min(pos+10, len(in))])
+}
+
+// Scan finds every non-overlapping match of p in in, skipping over anything p can't parse. This is how to pull
+// well-formed tokens out of corrupted text.
+func Scan[T any](p Parser[T], in []byte) iter.Seq[Span[T]] {
+	return func(yield func(Span[T]) bool) {
+		for pos := 0; pos < len(in); {
+			start, end, v, ok := first(p, in[pos:])
+			if !ok {
+				return
+			}
+			if !yield(Span[T]{Value: v, Start: pos + start, End: pos + end}) {
+				return
+			}
+			pos += max(end, start+1)
+		}
+	}
+}
+
+// ScanReader is Scan over a stream, keyed by byte offset. It holds a bounded window in memory, so no match may be
+// longer than maxLen.
+func ScanReader[T any](p Parser[T], r io.Reader, maxLen int) (iter.Seq2[int64, T], func() error) {
+	return common.Tokens(r, maxLen, func(buf []byte) (int, int, T, bool) {
+		return first(p, buf)
+	})
+}
+
+func first[T any](p Parser[T], in []byte) (start, end int, v T, ok bool) {
+	for start := range in {
+		if v, end, ok := p(in, start); ok {
+			return start, end, v, true
+		}
+	}
+	return 0, 0, v, false
+}
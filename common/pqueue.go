@@ -0,0 +1,101 @@
+package common
+
+import "container/heap"
+
+// PriorityQueue is a min-priority queue of distinct items that supports changing an item's priority in place
+// (decrease-key), as needed by Dijkstra and friends.
+type PriorityQueue[T comparable] struct {
+	h pqHeap[T]
+}
+
+func NewPriorityQueue[T comparable]() *PriorityQueue[T] {
+	return &PriorityQueue[T]{h: pqHeap[T]{index: map[T]int{}}}
+}
+
+func (pq *PriorityQueue[T]) Len() int {
+	return len(pq.h.items)
+}
+
+func (pq *PriorityQueue[T]) Contains(item T) bool {
+	_, ok := pq.h.index[item]
+	return ok
+}
+
+// Priority returns the current priority of item, if it's queued.
+func (pq *PriorityQueue[T]) Priority(item T) (int, bool) {
+	i, ok := pq.h.index[item]
+	if !ok {
+		return 0, false
+	}
+	return pq.h.items[i].prio, true
+}
+
+// Push queues item, or moves it to prio if it's already queued.
+func (pq *PriorityQueue[T]) Push(item T, prio int) {
+	if i, ok := pq.h.index[item]; ok {
+		pq.h.items[i].prio = prio
+		heap.Fix(&pq.h, i)
+		return
+	}
+	heap.Push(&pq.h, pqItem[T]{item, prio})
+}
+
+// DecreaseKey lowers item's priority to prio, queueing it if needed. It reports whether anything changed, so
+// callers can use it as the relax step of a shortest-path search.
+func (pq *PriorityQueue[T]) DecreaseKey(item T, prio int) bool {
+	if cur, ok := pq.Priority(item); ok && cur <= prio {
+		return false
+	}
+	pq.Push(item, prio)
+	return true
+}
+
+func (pq *PriorityQueue[T]) Peek() (T, int, bool) {
+	if len(pq.h.items) == 0 {
+		var zero T
+		return zero, 0, false
+	}
+	top := pq.h.items[0]
+	return top.item, top.prio, true
+}
+
+func (pq *PriorityQueue[T]) Pop() (T, int, bool) {
+	if len(pq.h.items) == 0 {
+		var zero T
+		return zero, 0, false
+	}
+	top := heap.Pop(&pq.h).(pqItem[T])
+	return top.item, top.prio, true
+}
+
+type pqItem[T any] struct {
+	item T
+	prio int
+}
+
+type pqHeap[T comparable] struct {
+	items []pqItem[T]
+	index map[T]int
+}
+
+func (h pqHeap[T]) Len() int           { return len(h.items) }
+func (h pqHeap[T]) Less(i, j int) bool { return h.items[i].prio < h.items[j].prio }
+
+func (h pqHeap[T]) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.index[h.items[i].item] = i
+	h.index[h.items[j].item] = j
+}
+
+func (h *pqHeap[T]) Push(x any) {
+	it := x.(pqItem[T])
+	h.index[it.item] = len(h.items)
+	h.items = append(h.items, it)
+}
+
+func (h *pqHeap[T]) Pop() any {
+	it := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	delete(h.index, it.item)
+	return it
+}
@@ -0,0 +1,90 @@
+package common
+
+// Region is a 4-connected group of equal-valued grid cells.
+type Region[T comparable] struct {
+	ID    int
+	Value T
+	Cells []Point
+
+	Area      int
+	Perimeter int
+	// Sides is the number of straight edge runs around the region (inside holes included), which is the same as
+	// its number of corners.
+	Sides int
+	// Min and Max are the corners of the bounding box, inclusive.
+	Min, Max Point
+}
+
+// Labeling is the result of LabelRegions: a region ID for each cell, and the regions themselves indexed by ID.
+type Labeling[T comparable] struct {
+	Labels  [][]int
+	Regions []*Region[T]
+}
+
+func (l *Labeling[T]) At(p Point) *Region[T] {
+	return l.Regions[l.Labels[p.Y][p.X]]
+}
+
+// LabelRegions groups g's cells into regions of equal, orthogonally adjacent values and measures each one. Region
+// IDs are assigned in row-major order of each region's first cell.
+func LabelRegions[G ~[][]T, T comparable](g G) *Labeling[T] {
+	offsets := make([]int, len(g)+1)
+	for y, row := range g {
+		offsets[y+1] = offsets[y] + len(row)
+	}
+	id := func(p Point) int { return offsets[p.Y] + p.X }
+
+	uf := newUnionFind(offsets[len(g)])
+	for y, row := range g {
+		for x, v := range row {
+			p := Point{x, y}
+			for _, d := range []Point{Right, Down} {
+				if n := p.Add(d); InGrid(g, n) && g[n.Y][n.X] == v {
+					uf.union(id(p), id(n))
+				}
+			}
+		}
+	}
+
+	l := &Labeling[T]{Labels: make([][]int, len(g))}
+	byRoot := map[int]*Region[T]{}
+	for y, row := range g {
+		l.Labels[y] = make([]int, len(row))
+		for x, v := range row {
+			p := Point{x, y}
+			root := uf.find(id(p))
+			r, ok := byRoot[root]
+			if !ok {
+				r = &Region[T]{ID: len(l.Regions), Value: v, Min: p, Max: p}
+				byRoot[root] = r
+				l.Regions = append(l.Regions, r)
+			}
+			l.Labels[y][x] = r.ID
+			r.Cells = append(r.Cells, p)
+			r.Area++
+			r.Min = Point{min(r.Min.X, x), min(r.Min.Y, y)}
+			r.Max = Point{max(r.Max.X, x), max(r.Max.Y, y)}
+		}
+	}
+
+	same := func(p Point, v T) bool { return InGrid(g, p) && g[p.Y][p.X] == v }
+	for _, r := range l.Regions {
+		for _, p := range r.Cells {
+			for _, d := range Dirs4 {
+				if !same(p.Add(d), r.Value) {
+					r.Perimeter++
+				}
+			}
+			// each pair of adjacent orthogonal directions is a potential corner: convex if both neighbours are
+			// outside, concave if both are inside but the diagonal between them isn't
+			for i, a := range Dirs4 {
+				b := Dirs4[(i+1)%len(Dirs4)]
+				inA, inB := same(p.Add(a), r.Value), same(p.Add(b), r.Value)
+				if !inA && !inB || inA && inB && !same(p.Add(a).Add(b), r.Value) {
+					r.Sides++
+				}
+			}
+		}
+	}
+	return l
+}
@@ -0,0 +1,85 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+// Table is one section of a report.
+type Table struct {
+	Title  string
+	Header []string
+	Rows   [][]string
+}
+
+// AddRow appends a row, formatting each value with fmt's %v.
+func (t *Table) AddRow(values ...any) {
+	row := make([]string, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case string:
+			row[i] = v
+		case int:
+			row[i] = strconv.Itoa(v)
+		default:
+			row[i] = fmt.Sprint(v)
+		}
+	}
+	t.Rows = append(t.Rows, row)
+}
+
+// Write writes tables to w in the given format: "table" for aligned columns under a title, or "csv", where each
+// table is a header row and its rows, and tables are separated by a blank line.
+func Write(w io.Writer, format string, tables ...Table) error {
+	switch format {
+	case "table", "":
+		return writeText(w, tables)
+	case "csv":
+		return writeCSV(w, tables)
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
+}
+
+func writeText(w io.Writer, tables []Table) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	for i, t := range tables {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		if t.Title != "" {
+			// a title has no tabs, so it doesn't disturb the columns
+			fmt.Fprintf(tw, "%s\n", t.Title)
+		}
+		for _, row := range append([][]string{t.Header}, t.Rows...) {
+			for _, cell := range row {
+				fmt.Fprintf(tw, "%s\t", cell)
+			}
+			fmt.Fprintln(tw)
+		}
+	}
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, tables []Table) error {
+	cw := csv.NewWriter(w)
+	for i, t := range tables {
+		if i > 0 {
+			cw.Flush()
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if err := cw.Write(t.Header); err != nil {
+			return err
+		}
+		if err := cw.WriteAll(t.Rows); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
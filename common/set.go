@@ -0,0 +1,115 @@
+package common
+
+import (
+	"iter"
+	"maps"
+	"slices"
+)
+
+// Set is a map-backed set. The zero value is not usable; make one with NewSet or a literal.
+type Set[T comparable] map[T]struct{}
+
+func NewSet[T comparable](items ...T) Set[T] {
+	s := make(Set[T], len(items))
+	for _, item := range items {
+		s[item] = struct{}{}
+	}
+	return s
+}
+
+func (s Set[T]) Add(items ...T) {
+	for _, item := range items {
+		s[item] = struct{}{}
+	}
+}
+
+func (s Set[T]) Remove(items ...T) {
+	for _, item := range items {
+		delete(s, item)
+	}
+}
+
+func (s Set[T]) Has(item T) bool {
+	_, ok := s[item]
+	return ok
+}
+
+func (s Set[T]) Len() int {
+	return len(s)
+}
+
+func (s Set[T]) Clone() Set[T] {
+	return maps.Clone(s)
+}
+
+// All iterates in map order. Use Sorted when the order matters.
+func (s Set[T]) All() iter.Seq[T] {
+	return maps.Keys(s)
+}
+
+// Sorted returns the items ordered by cmp, for reproducible output.
+func (s Set[T]) Sorted(cmp func(a, b T) int) []T {
+	return slices.SortedFunc(maps.Keys(s), cmp)
+}
+
+func (s Set[T]) Union(o Set[T]) Set[T] {
+	out := make(Set[T], max(len(s), len(o)))
+	for item := range s {
+		out[item] = struct{}{}
+	}
+	for item := range o {
+		out[item] = struct{}{}
+	}
+	return out
+}
+
+func (s Set[T]) Intersect(o Set[T]) Set[T] {
+	small, big := s, o
+	if len(big) < len(small) {
+		small, big = big, small
+	}
+	out := make(Set[T])
+	for item := range small {
+		if big.Has(item) {
+			out[item] = struct{}{}
+		}
+	}
+	return out
+}
+
+// Difference is the items of s that are not in o.
+func (s Set[T]) Difference(o Set[T]) Set[T] {
+	out := make(Set[T])
+	for item := range s {
+		if !o.Has(item) {
+			out[item] = struct{}{}
+		}
+	}
+	return out
+}
+
+func (s Set[T]) SymmetricDifference(o Set[T]) Set[T] {
+	out := s.Difference(o)
+	for item := range o {
+		if !s.Has(item) {
+			out[item] = struct{}{}
+		}
+	}
+	return out
+}
+
+func (s Set[T]) IsSubset(o Set[T]) bool {
+	if len(s) > len(o) {
+		return false
+	}
+	for item := range s {
+		if !o.Has(item) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Set[T]) Equal(o Set[T]) bool {
+	return len(s) == len(o) && s.IsSubset(o)
+}
@@ -0,0 +1,126 @@
+package common
+
+import (
+	"bufio"
+	"io"
+	"iter"
+	"regexp"
+	"strings"
+)
+
+// MaxLineLen is the longest line Lines will read before failing with bufio.ErrTooLong.
+const MaxLineLen = 64 << 20
+
+// Lines iterates over r's lines, keyed by 1-based line number. The returned func reports any read error once the
+// loop is done.
+func Lines(r io.Reader) (iter.Seq2[int, string], func() error) {
+	var err error
+	seq := func(yield func(int, string) bool) {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64<<10), MaxLineLen)
+		lineNo := 0
+		for scanner.Scan() {
+			lineNo++
+			if !yield(lineNo, strings.TrimSuffix(scanner.Text(), "\r")) {
+				return
+			}
+		}
+		err = scanner.Err()
+	}
+	return seq, func() error { return err }
+}
+
+// Records iterates over blank-line-separated groups of lines, keyed by the line number each group starts on.
+func Records(r io.Reader) (iter.Seq2[int, []string], func() error) {
+	lines, errf := Lines(r)
+	seq := func(yield func(int, []string) bool) {
+		start := 0
+		var record []string
+		for lineNo, line := range lines {
+			if strings.TrimSpace(line) == "" {
+				if len(record) > 0 && !yield(start, record) {
+					return
+				}
+				record = nil
+				continue
+			}
+			if len(record) == 0 {
+				start = lineNo
+			}
+			record = append(record, line)
+		}
+		if len(record) > 0 {
+			yield(start, record)
+		}
+	}
+	return seq, errf
+}
+
+const scanChunk = 64 << 10
+
+// Matches iterates over the non-overlapping matches of rx in r, keyed by byte offset. See Tokens for the memory and
+// boundary guarantees; anchors (^, $, \b) are evaluated against the current window rather than the whole stream.
+// The yielded slice is only valid until the next iteration.
+func Matches(r io.Reader, rx *regexp.Regexp, maxLen int) (iter.Seq2[int64, []byte], func() error) {
+	return Tokens(r, maxLen, func(buf []byte) (int, int, []byte, bool) {
+		loc := rx.FindIndex(buf)
+		if loc == nil {
+			return 0, 0, nil, false
+		}
+		return loc[0], loc[1], buf[loc[0]:loc[1]], true
+	})
+}
+
+// Tokens iterates over the non-overlapping tokens in r, keyed by byte offset, holding at most 64KiB+maxLen bytes in
+// memory. find reports the first token in buf as buf[start:end] along with its value. Tokens may span read
+// boundaries as long as they are no longer than maxLen.
+func Tokens[T any](r io.Reader, maxLen int, find func(buf []byte) (start, end int, v T, ok bool)) (iter.Seq2[int64, T], func() error) {
+	var err error
+	seq := func(yield func(int64, T) bool) {
+		buf := make([]byte, 0, scanChunk+maxLen)
+		var base int64 // stream offset of buf[0]
+		eof := false
+		for {
+			for !eof && len(buf) < cap(buf) {
+				n, rerr := r.Read(buf[len(buf):cap(buf)])
+				buf = buf[:len(buf)+n]
+				if rerr == io.EOF {
+					eof = true
+				} else if rerr != nil {
+					err = rerr
+					return
+				}
+			}
+
+			// a token starting at or after limit might not be complete yet, so it waits for the next window
+			limit := len(buf) - maxLen
+			if eof {
+				limit = len(buf)
+			}
+			pos := 0
+			for pos <= limit {
+				start, end, v, ok := find(buf[pos:])
+				if !ok || pos+start >= limit && !eof {
+					break
+				}
+				start, end = pos+start, pos+end
+				if !yield(base+int64(start), v) {
+					return
+				}
+				if end == start {
+					end++ // step over empty tokens
+				}
+				pos = end
+			}
+			if eof {
+				return
+			}
+
+			keep := max(pos, limit)
+			n := copy(buf, buf[keep:])
+			buf = buf[:n]
+			base += int64(keep)
+		}
+	}
+	return seq, func() error { return err }
+}
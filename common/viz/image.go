@@ -0,0 +1,267 @@
+package viz
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Palette maps cell characters to colors for image output. Characters it doesn't mention get one of a fixed set of
+// pastel colors, picked by the character so it's stable between runs.
+type Palette map[rune]color.RGBA
+
+var DefaultPalette = Palette{
+	'.': {0xf4, 0xf4, 0xf0, 0xff},
+	'#': {0x3a, 0x3a, 0x42, 0xff},
+	' ': {0xff, 0xff, 0xff, 0xff},
+}
+
+var fallbackColors = []color.RGBA{
+	{0xfb, 0xb4, 0xae, 0xff}, {0xb3, 0xcd, 0xe3, 0xff}, {0xcc, 0xeb, 0xc5, 0xff}, {0xde, 0xcb, 0xe4, 0xff},
+	{0xfe, 0xd9, 0xa6, 0xff}, {0xff, 0xff, 0xcc, 0xff}, {0xe5, 0xd8, 0xbd, 0xff}, {0xfd, 0xda, 0xec, 0xff},
+}
+
+// overlayColors are the image equivalents of the terminal colors
+var overlayColors = map[Color]color.RGBA{
+	Red:     {0xd6, 0x27, 0x28, 0xff},
+	Green:   {0x2c, 0xa0, 0x2c, 0xff},
+	Yellow:  {0xf2, 0xc1, 0x0f, 0xff},
+	Blue:    {0x1f, 0x77, 0xb4, 0xff},
+	Magenta: {0xc2, 0x3b, 0xb5, 0xff},
+	Cyan:    {0x17, 0xbe, 0xcf, 0xff},
+	Grey:    {0x99, 0x99, 0x99, 0xff},
+}
+
+func (p Palette) color(c cellStyle) color.RGBA {
+	if c.color != NoColor {
+		return overlayColors[c.color]
+	}
+	if col, ok := p[c.r]; ok {
+		return col
+	}
+	return fallbackColors[int(c.r)%len(fallbackColors)]
+}
+
+// gifPalette is every color an image frame can contain
+func (p Palette) gifPalette() color.Palette {
+	var pal color.Palette
+	seen := map[color.RGBA]bool{}
+	add := func(c color.RGBA) {
+		if !seen[c] && len(pal) < 256 {
+			seen[c] = true
+			pal = append(pal, c)
+		}
+	}
+	for _, c := range p {
+		add(c)
+	}
+	for _, c := range fallbackColors {
+		add(c)
+	}
+	for col := Red; col <= Grey; col++ {
+		add(overlayColors[col])
+	}
+	return pal
+}
+
+// maxGIFFrames bounds how many frames an animated GIF keeps in memory. Past that, every other frame is dropped and
+// only every second frame from then on is kept, and so on.
+const maxGIFFrames = 512
+
+// ImageRenderer writes frames to an image file when it's closed: every frame as an animated GIF, or the last frame
+// as a PNG or SVG, depending on the file extension.
+type ImageRenderer struct {
+	path    string
+	format  string
+	cell    int // pixels per grid cell
+	delay   int // between GIF frames, in 1/100s
+	palette Palette
+
+	last   *Frame
+	frames []*image.Paletted
+	stride int // keep every stride'th frame
+	seen   int
+}
+
+func NewImageRenderer(path string, fps int, palette Palette) (*ImageRenderer, error) {
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	switch format {
+	case "gif", "png", "svg":
+	default:
+		return nil, fmt.Errorf("can't render to %q: want a .gif, .png or .svg file", path)
+	}
+	if palette == nil {
+		palette = DefaultPalette
+	}
+	return &ImageRenderer{path: path, format: format, delay: max(100/max(fps, 1), 2), palette: palette, stride: 1}, nil
+}
+
+func (ir *ImageRenderer) Render(f Frame) error {
+	if ir.cell == 0 {
+		// aim for images around 600px across
+		width := 0
+		for _, row := range f.Grid {
+			width = max(width, len(row))
+		}
+		ir.cell = max(1, min(16, 600/max(width, len(f.Grid), 1)))
+	}
+	ir.last = &f
+	if ir.format != "gif" {
+		return nil
+	}
+
+	ir.seen++
+	if (ir.seen-1)%ir.stride != 0 {
+		return nil
+	}
+	ir.frames = append(ir.frames, ir.paletted(f))
+	if len(ir.frames) >= maxGIFFrames {
+		kept := ir.frames[:0]
+		for i := 0; i < len(ir.frames); i += 2 {
+			kept = append(kept, ir.frames[i])
+		}
+		ir.frames = kept
+		ir.stride *= 2
+	}
+	return nil
+}
+
+func (ir *ImageRenderer) Close() error {
+	if ir.last == nil {
+		return nil
+	}
+	f, err := os.Create(ir.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	switch ir.format {
+	case "gif":
+		// always end on the final state, even if it fell between kept frames
+		if (ir.seen-1)%ir.stride != 0 {
+			ir.frames = append(ir.frames, ir.paletted(*ir.last))
+		}
+		delays := make([]int, len(ir.frames))
+		for i := range delays {
+			delays[i] = ir.delay * ir.stride
+		}
+		delays[len(delays)-1] = 300 // linger on the result
+		err = gif.EncodeAll(f, &gif.GIF{Image: ir.frames, Delay: delays})
+	case "png":
+		err = png.Encode(f, ir.paletted(*ir.last))
+	case "svg":
+		err = ir.writeSVG(f, *ir.last)
+	}
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func (ir *ImageRenderer) paletted(f Frame) *image.Paletted {
+	cells := f.compose()
+	width := 0
+	for _, row := range cells {
+		width = max(width, len(row))
+	}
+	img := image.NewPaletted(image.Rect(0, 0, width*ir.cell, len(cells)*ir.cell), ir.palette.gifPalette())
+	for y, row := range cells {
+		for x, c := range row {
+			idx := uint8(img.Palette.Index(ir.palette.color(c)))
+			for py := y * ir.cell; py < (y+1)*ir.cell; py++ {
+				for px := x * ir.cell; px < (x+1)*ir.cell; px++ {
+					img.SetColorIndex(px, py, idx)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// writeSVG draws one rect per cell, with the cell's character on top when cells are big enough to read
+func (ir *ImageRenderer) writeSVG(w io.Writer, f Frame) error {
+	const cell = 16
+	cells := f.compose()
+	width := 0
+	for _, row := range cells {
+		width = max(width, len(row))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="monospace" font-size="%d" text-anchor="middle">`+"\n",
+		width*cell, len(cells)*cell, cell*3/4)
+	if f.Caption != "" {
+		fmt.Fprintf(&b, "<title>%s</title>\n", html.EscapeString(f.Caption))
+	}
+	for y, row := range cells {
+		for x, c := range row {
+			col := ir.palette.color(c)
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="#%02x%02x%02x"/>`, x*cell, y*cell, cell, cell, col.R, col.G, col.B)
+			if c.r != ' ' && c.r != '.' {
+				fmt.Fprintf(&b, `<text x="%d" y="%d">%s</text>`, x*cell+cell/2, y*cell+cell*3/4, html.EscapeString(string(c.r)))
+			}
+			b.WriteByte('\n')
+		}
+	}
+	b.WriteString("</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// multi fans frames out to several renderers
+type multi []Renderer
+
+func (m multi) Render(f Frame) error {
+	for _, r := range m {
+		if err := r.Render(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m multi) Close() error {
+	var firstErr error
+	for _, r := range m {
+		if err := r.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Open sets up every renderer asked for: a live one by name (see New) and/or an image file (see
+// NewImageRenderer). It returns nil if neither is.
+func Open(kind, imagePath string, fps int) (Renderer, error) {
+	var rs multi
+	live, err := New(kind, fps)
+	if err != nil {
+		return nil, err
+	}
+	if live != nil {
+		rs = append(rs, live)
+	}
+	if imagePath != "" {
+		ir, err := NewImageRenderer(imagePath, fps, nil)
+		if err != nil {
+			return nil, err
+		}
+		rs = append(rs, ir)
+	}
+	switch len(rs) {
+	case 0:
+		return nil, nil
+	case 1:
+		return rs[0], nil
+	default:
+		return rs, nil
+	}
+}
@@ -0,0 +1,273 @@
+package viz
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.coldcutz.net/advent2024/common"
+)
+
+type Color int
+
+const (
+	NoColor Color = iota
+	Red
+	Green
+	Yellow
+	Blue
+	Magenta
+	Cyan
+	Grey
+)
+
+// ansi is the SGR foreground code for each color
+var ansi = map[Color]string{
+	Red:     "31",
+	Green:   "32",
+	Yellow:  "33",
+	Blue:    "34",
+	Magenta: "35",
+	Cyan:    "36",
+	Grey:    "90",
+}
+
+// Overlay marks some cells of a frame. A zero Rune leaves the cell's own character showing.
+type Overlay struct {
+	Name  string
+	Cells []common.Point
+	Rune  rune
+	Color Color
+}
+
+// Frame is one picture of a solver's state: a grid of characters, with overlays drawn over it in order.
+type Frame struct {
+	Step     int
+	Caption  string
+	Grid     [][]rune
+	Overlays []Overlay
+}
+
+// GridFrame copies any rune-ish grid into a frame.
+func GridFrame[G ~[][]T, T ~rune](g G) Frame {
+	grid := make([][]rune, len(g))
+	for y, row := range g {
+		grid[y] = make([]rune, len(row))
+		for x, r := range row {
+			grid[y][x] = rune(r)
+		}
+	}
+	return Frame{Grid: grid}
+}
+
+// Path is an overlay for a walked path. Later cells are drawn over earlier ones.
+func Path(name string, cells []common.Point, color Color) Overlay {
+	return Overlay{Name: name, Cells: cells, Color: color}
+}
+
+// Heading is an overlay showing something at p facing dir, as an arrow.
+func Heading(p, dir common.Point, color Color) Overlay {
+	arrows := map[common.Point]rune{common.Up: '^', common.Down: 'v', common.Left: '<', common.Right: '>'}
+	r, ok := arrows[dir]
+	if !ok {
+		r = '@'
+	}
+	return Overlay{Name: "heading", Cells: []common.Point{p}, Rune: r, Color: color}
+}
+
+// Highlight is an overlay that colors cells, optionally replacing their character.
+func Highlight(name string, cells []common.Point, r rune, color Color) Overlay {
+	return Overlay{Name: name, Cells: cells, Rune: r, Color: color}
+}
+
+type cellStyle struct {
+	r     rune
+	color Color
+}
+
+// compose flattens a frame's overlays onto its grid
+func (f Frame) compose() [][]cellStyle {
+	out := make([][]cellStyle, len(f.Grid))
+	for y, row := range f.Grid {
+		out[y] = make([]cellStyle, len(row))
+		for x, r := range row {
+			out[y][x] = cellStyle{r: r}
+		}
+	}
+	for _, o := range f.Overlays {
+		for _, p := range o.Cells {
+			if !common.InGrid(out, p) {
+				continue
+			}
+			c := &out[p.Y][p.X]
+			if o.Rune != 0 {
+				c.r = o.Rune
+			}
+			c.color = o.Color
+		}
+	}
+	return out
+}
+
+// Renderer consumes frames as a solver produces them.
+type Renderer interface {
+	Render(Frame) error
+	Close() error
+}
+
+// New makes a renderer by name: "ansi" for an animation in the terminal, "text" for a plain dump of every frame,
+// or "" for none, in which case it returns nil. Solvers should skip building frames when their renderer is nil.
+func New(kind string, fps int) (Renderer, error) {
+	switch kind {
+	case "":
+		return nil, nil
+	case "text":
+		return NewTextRenderer(os.Stdout), nil
+	case "ansi":
+		return NewANSIRenderer(os.Stdout, fps, os.Stdin), nil
+	default:
+		return nil, fmt.Errorf("unknown renderer %q", kind)
+	}
+}
+
+// TextRenderer writes each frame as plain text, with a header line, for diffing or grepping.
+type TextRenderer struct {
+	w io.Writer
+}
+
+func NewTextRenderer(w io.Writer) *TextRenderer {
+	return &TextRenderer{w: w}
+}
+
+func (t *TextRenderer) Render(f Frame) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "=== frame %d", f.Step)
+	if f.Caption != "" {
+		fmt.Fprintf(&b, ": %s", f.Caption)
+	}
+	b.WriteByte('\n')
+	for _, row := range f.compose() {
+		for _, c := range row {
+			b.WriteRune(c.r)
+		}
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(t.w, b.String())
+	return err
+}
+
+func (t *TextRenderer) Close() error {
+	return nil
+}
+
+// ANSIRenderer animates frames in place in a terminal. If it's given a controls reader, it reads one command per
+// line from it while animating:
+//
+//	(empty)  pause, or step one frame if already paused
+//	p        pause / resume
+//	+ / -    double / halve the frame rate
+//	q        stop animating and let the solver finish
+type ANSIRenderer struct {
+	w io.Writer
+
+	mu       sync.Mutex
+	interval time.Duration
+	paused   bool
+	quit     bool
+	steps    chan struct{}
+}
+
+func NewANSIRenderer(w io.Writer, fps int, controls io.Reader) *ANSIRenderer {
+	a := &ANSIRenderer{w: w, interval: time.Second / time.Duration(max(fps, 1)), steps: make(chan struct{}, 1)}
+	if controls != nil {
+		go a.readControls(controls)
+	}
+	return a
+}
+
+func (a *ANSIRenderer) readControls(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		a.mu.Lock()
+		switch strings.TrimSpace(scanner.Text()) {
+		case "":
+			if a.paused {
+				select {
+				case a.steps <- struct{}{}:
+				default:
+				}
+			}
+			a.paused = true
+		case "p":
+			a.paused = !a.paused
+			if !a.paused {
+				select {
+				case a.steps <- struct{}{}:
+				default:
+				}
+			}
+		case "+":
+			a.interval = max(a.interval/2, time.Millisecond)
+		case "-":
+			a.interval *= 2
+		case "q":
+			a.quit = true
+			select {
+			case a.steps <- struct{}{}:
+			default:
+			}
+		}
+		a.mu.Unlock()
+	}
+}
+
+func (a *ANSIRenderer) Render(f Frame) error {
+	a.mu.Lock()
+	quit, paused, interval := a.quit, a.paused, a.interval
+	a.mu.Unlock()
+	if quit {
+		return nil
+	}
+
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J") // home and clear
+	fmt.Fprintf(&b, "step %d", f.Step)
+	if f.Caption != "" {
+		fmt.Fprintf(&b, "  %s", f.Caption)
+	}
+	b.WriteString("\n")
+	for _, row := range f.compose() {
+		cur := NoColor
+		for _, c := range row {
+			if c.color != cur {
+				if c.color == NoColor {
+					b.WriteString("\x1b[0m")
+				} else {
+					fmt.Fprintf(&b, "\x1b[%sm", ansi[c.color])
+				}
+				cur = c.color
+			}
+			b.WriteRune(c.r)
+		}
+		b.WriteString("\x1b[0m\n")
+	}
+	if _, err := io.WriteString(a.w, b.String()); err != nil {
+		return err
+	}
+
+	if paused {
+		<-a.steps
+	} else {
+		time.Sleep(interval)
+	}
+	return nil
+}
+
+func (a *ANSIRenderer) Close() error {
+	_, err := io.WriteString(a.w, "\x1b[0m")
+	return err
+}
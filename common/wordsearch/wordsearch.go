@@ -0,0 +1,268 @@
+package wordsearch
+
+import (
+	"cmp"
+	"slices"
+
+	"go.coldcutz.net/advent2024/common"
+)
+
+type Options struct {
+	// Wrap lets words run off one edge of the grid and continue from the opposite one.
+	Wrap bool
+	// Bent finds boggle-style words: each letter may be any of the 8 neighbours of the previous one, and no cell
+	// is used twice in a word. Straight words are found too, since they're a special case.
+	Bent bool
+}
+
+// Hit is one occurrence of a word. Dir is the step between letters for a straight word and zero for a bent one;
+// Path is every cell of the word in order.
+type Hit struct {
+	Word       string
+	Start, End common.Point
+	Dir        common.Point
+	Path       []common.Point
+}
+
+// Searcher finds a fixed dictionary of words in grids, using an Aho-Corasick automaton so the whole dictionary is
+// matched in one pass over each row, column and diagonal.
+type Searcher struct {
+	words []string
+	lens  []int
+	nodes []node
+}
+
+type node struct {
+	next map[rune]int
+	fail int
+	out  []int // indices of the words that end here, including via fail links
+}
+
+func New(words ...string) *Searcher {
+	s := &Searcher{words: words, nodes: []node{{next: map[rune]int{}}}}
+	for wi, w := range words {
+		cur := 0
+		for _, r := range w {
+			n, ok := s.nodes[cur].next[r]
+			if !ok {
+				n = len(s.nodes)
+				s.nodes = append(s.nodes, node{next: map[rune]int{}})
+				s.nodes[cur].next[r] = n
+			}
+			cur = n
+		}
+		s.nodes[cur].out = append(s.nodes[cur].out, wi)
+		s.lens = append(s.lens, len([]rune(w)))
+	}
+
+	// breadth-first, so a node's fail target is always finished before the node itself
+	var queue common.Deque[int]
+	for _, n := range s.nodes[0].next {
+		queue.PushBack(n)
+	}
+	for queue.Len() > 0 {
+		cur, _ := queue.PopFront()
+		for r, n := range s.nodes[cur].next {
+			f := s.nodes[cur].fail
+			for f != 0 && !s.has(f, r) {
+				f = s.nodes[f].fail
+			}
+			if t, ok := s.nodes[f].next[r]; ok && t != n {
+				s.nodes[n].fail = t
+			}
+			s.nodes[n].out = append(s.nodes[n].out, s.nodes[s.nodes[n].fail].out...)
+			queue.PushBack(n)
+		}
+	}
+	return s
+}
+
+func (s *Searcher) has(n int, r rune) bool {
+	_, ok := s.nodes[n].next[r]
+	return ok
+}
+
+func (s *Searcher) step(cur int, r rune) int {
+	for {
+		if n, ok := s.nodes[cur].next[r]; ok {
+			return n
+		}
+		if cur == 0 {
+			return 0
+		}
+		cur = s.nodes[cur].fail
+	}
+}
+
+func (s *Searcher) maxLen() int {
+	m := 0
+	for _, l := range s.lens {
+		m = max(m, l)
+	}
+	return m
+}
+
+// Search returns every hit in g, ordered by start (row-major), then direction (clockwise from up), then word.
+// Rows are expected to all be the same length when wrapping.
+func Search[G ~[][]T, T ~rune](s *Searcher, g G, opts Options) []Hit {
+	var hits []Hit
+	if opts.Bent {
+		hits = searchBent(s, g)
+	} else {
+		for _, d := range []common.Point{common.Right, common.Down, common.DownRight, common.DownLeft} {
+			for _, line := range lines(g, d, opts.Wrap) {
+				hits = scanLine(s, g, line, d, hits)
+				hits = scanLine(s, g, reversed(line), common.Point{X: -d.X, Y: -d.Y}, hits)
+			}
+		}
+	}
+
+	dirIndex := func(d common.Point) int { return slices.Index(common.Dirs8, d) }
+	slices.SortFunc(hits, func(a, b Hit) int {
+		return cmp.Or(
+			cmp.Compare(a.Start.Y, b.Start.Y),
+			cmp.Compare(a.Start.X, b.Start.X),
+			cmp.Compare(dirIndex(a.Dir), dirIndex(b.Dir)),
+			cmp.Compare(a.Word, b.Word),
+			slices.CompareFunc(a.Path, b.Path, func(p, q common.Point) int {
+				return cmp.Or(cmp.Compare(p.Y, q.Y), cmp.Compare(p.X, q.X))
+			}),
+		)
+	})
+	return hits
+}
+
+// line is the cells along one row, column or diagonal; cyclic when wrapping
+type line struct {
+	cells  []common.Point
+	cyclic bool
+}
+
+func reversed(l line) line {
+	cells := slices.Clone(l.cells)
+	slices.Reverse(cells)
+	return line{cells, l.cyclic}
+}
+
+// lines returns the maximal runs of cells in direction d. When wrapping they're the cycles you get by stepping in d
+// modulo the grid size, which covers every cell exactly once.
+func lines[G ~[][]T, T ~rune](g G, d common.Point, wrap bool) []line {
+	if len(g) == 0 {
+		return nil
+	}
+	height, width := len(g), 0
+	for _, row := range g {
+		width = max(width, len(row))
+	}
+
+	var out []line
+	if wrap {
+		seen := common.NewGridBitset(width, height, 1)
+		for y := range height {
+			for x := range width {
+				if seen.Test(x, y, 0) {
+					continue
+				}
+				var l line
+				l.cyclic = true
+				for p := (common.Point{X: x, Y: y}); seen.Set(p.X, p.Y, 0); {
+					l.cells = append(l.cells, p)
+					p = common.Point{X: ((p.X+d.X)%width + width) % width, Y: ((p.Y+d.Y)%height + height) % height}
+				}
+				out = append(out, l)
+			}
+		}
+		return out
+	}
+
+	// a line starts at every cell whose predecessor in direction d is off the grid
+	for y := range height {
+		for x := range width {
+			if x-d.X >= 0 && x-d.X < width && y-d.Y >= 0 && y-d.Y < height {
+				continue
+			}
+			var l line
+			for p := (common.Point{X: x, Y: y}); p.X >= 0 && p.X < width && p.Y >= 0 && p.Y < height; p = p.Add(d) {
+				l.cells = append(l.cells, p)
+			}
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+const gap rune = -1 // stands in for cells missing from ragged rows, and never matches
+
+func cellAt[G ~[][]T, T ~rune](g G, p common.Point) rune {
+	if !common.InGrid(g, p) {
+		return gap
+	}
+	return rune(g[p.Y][p.X])
+}
+
+// scanLine feeds one line through the automaton. A cyclic line is fed round again far enough to catch words that
+// wrap past its end.
+func scanLine[G ~[][]T, T ~rune](s *Searcher, g G, l line, d common.Point, hits []Hit) []Hit {
+	n := len(l.cells)
+	total := n
+	if l.cyclic {
+		total += s.maxLen() - 1
+	}
+	cur := 0
+	for i := range total {
+		cur = s.step(cur, cellAt(g, l.cells[i%n]))
+		for _, wi := range s.nodes[cur].out {
+			length := s.lens[wi]
+			start := i - length + 1
+			if l.cyclic && start >= n {
+				continue // already found on the first time round
+			}
+			if length == 0 || start < 0 {
+				continue
+			}
+			path := make([]common.Point, length)
+			for k := range length {
+				path[k] = l.cells[(start+k)%n]
+			}
+			hits = append(hits, Hit{Word: s.words[wi], Start: path[0], End: path[length-1], Dir: d, Path: path})
+		}
+	}
+	return hits
+}
+
+// searchBent walks the trie depth-first from every cell, stepping to any unused neighbour
+func searchBent[G ~[][]T, T ~rune](s *Searcher, g G) []Hit {
+	var hits []Hit
+	var path []common.Point
+	used := map[common.Point]bool{}
+
+	var walk func(p common.Point, n int)
+	walk = func(p common.Point, n int) {
+		next, ok := s.nodes[n].next[cellAt(g, p)]
+		if !ok {
+			return
+		}
+		path = append(path, p)
+		used[p] = true
+		for _, wi := range s.nodes[next].out {
+			// only words ending exactly here; fail-link outputs are suffixes that started somewhere else
+			if s.lens[wi] == len(path) {
+				hits = append(hits, Hit{Word: s.words[wi], Start: path[0], End: p, Path: slices.Clone(path)})
+			}
+		}
+		for _, d := range common.Dirs8 {
+			if q := p.Add(d); common.InGrid(g, q) && !used[q] {
+				walk(q, next)
+			}
+		}
+		used[p] = false
+		path = path[:len(path)-1]
+	}
+
+	for y, row := range g {
+		for x := range row {
+			walk(common.Point{X: x, Y: y}, 0)
+		}
+	}
+	return hits
+}
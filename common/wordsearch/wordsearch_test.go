@@ -0,0 +1,181 @@
+package wordsearch
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.coldcutz.net/advent2024/common"
+)
+
+func grid(s string) [][]rune {
+	var g [][]rune
+	for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		g = append(g, []rune(line))
+	}
+	return g
+}
+
+// summary is a hit as "WORD@x,y>dx,dy", for comparing against expectations
+func summary(h Hit) string {
+	return fmt.Sprintf("%s@%d,%d>%d,%d", h.Word, h.Start.X, h.Start.Y, h.Dir.X, h.Dir.Y)
+}
+
+func summaries(hits []Hit) []string {
+	out := make([]string, len(hits))
+	for i, h := range hits {
+		out[i] = summary(h)
+	}
+	return out
+}
+
+func TestXMASExample(t *testing.T) {
+	g := grid(`
+MMMSXXMASM
+MSAMXMSMSA
+AMXSXMAAMM
+MSAMASMSMX
+XMASAMXAMM
+XXAMMXXAMA
+SMSMSASXSS
+SAXAMASAAA
+MAMMMXMMMM
+MXMXAXMASX
+`)
+	hits := Search(New("XMAS"), g, Options{})
+	if len(hits) != 18 {
+		t.Errorf("found %d, want 18", len(hits))
+	}
+	for _, h := range hits {
+		var word []rune
+		for _, p := range h.Path {
+			word = append(word, g[p.Y][p.X])
+		}
+		if string(word) != "XMAS" || h.Start != h.Path[0] || h.End != h.Path[3] || h.Path[1] != h.Start.Add(h.Dir) {
+			t.Errorf("bad hit %+v spells %q", h, string(word))
+		}
+	}
+}
+
+func TestOverlappingWords(t *testing.T) {
+	// ABC contains AB and BC, and CAB ends in AB, so some words are only found through fail links
+	hits := Search(New("AB", "ABC", "BC", "CAB"), grid("ABCAB"), Options{})
+	want := []string{"AB@0,0>1,0", "ABC@0,0>1,0", "BC@1,0>1,0", "CAB@2,0>1,0", "AB@3,0>1,0"}
+	if got := summaries(hits); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	g := grid(`
+XAQS
+EBCF
+GMDH
+`)
+	tests := []struct {
+		word string
+		want []string
+	}{
+		// off the right edge of the top row and back in on the left
+		{"SXA", []string{"SXA@3,0>1,0"}},
+		// off the bottom and back in at the top
+		{"MA", []string{"MA@1,2>0,1"}},
+		// diagonally off the bottom and the right, on a grid that isn't square
+		{"DSE", []string{"DSE@2,2>1,1"}},
+		// longer than the row, so it comes round to S again
+		{"SXAQS", []string{"SXAQS@3,0>1,0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if hits := Search(New(tt.word), g, Options{}); len(hits) > 0 {
+				t.Errorf("found %v without wrapping", summaries(hits))
+			}
+			if got := summaries(Search(New(tt.word), g, Options{Wrap: true})); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// bruteForce finds words by trying every start, direction and word
+func bruteForce(g [][]rune, words []string, wrap bool) []string {
+	height, width := len(g), len(g[0])
+	var out []string
+	for y := range height {
+		for x := range width {
+			for _, d := range common.Dirs8 {
+				for _, w := range words {
+					ok := true
+					for k, r := range []rune(w) {
+						px, py := x+k*d.X, y+k*d.Y
+						if wrap {
+							px, py = (px%width+width)%width, (py%height+height)%height
+						}
+						if px < 0 || px >= width || py < 0 || py >= height || g[py][px] != r {
+							ok = false
+							break
+						}
+					}
+					if ok {
+						out = append(out, fmt.Sprintf("%s@%d,%d>%d,%d", w, x, y, d.X, d.Y))
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+func TestMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	words := []string{"AB", "ABA", "BAB", "ABBA", "CAB", "AAAAA"}
+	s := New(words...)
+	for range 200 {
+		g := make([][]rune, 1+rng.IntN(5))
+		width := 1 + rng.IntN(5)
+		for y := range g {
+			g[y] = make([]rune, width)
+			for x := range g[y] {
+				g[y][x] = rune("ABC"[rng.IntN(3)])
+			}
+		}
+		for _, wrap := range []bool{false, true} {
+			got := summaries(Search(s, g, Options{Wrap: wrap}))
+			want := bruteForce(g, words, wrap)
+			slices.Sort(got)
+			slices.Sort(want)
+			if !slices.Equal(got, want) {
+				t.Fatalf("wrap=%t on %q: got %v, want %v", wrap, g, got, want)
+			}
+		}
+	}
+}
+
+func TestBent(t *testing.T) {
+	g := grid(`
+XM
+SA
+`)
+	hits := Search(New("XMAS", "SAM", "MAX", "XMX"), g, Options{Bent: true})
+	want := map[string][]common.Point{
+		"XMAS": {{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}},
+		"SAM":  {{X: 0, Y: 1}, {X: 1, Y: 1}, {X: 1, Y: 0}},
+		"MAX":  {{X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 0}},
+	}
+	if len(hits) != len(want) {
+		t.Fatalf("got %v, want one hit for each of XMAS, SAM and MAX (XMX would reuse a cell)", summaries(hits))
+	}
+	for _, h := range hits {
+		if !slices.Equal(h.Path, want[h.Word]) || h.Dir != (common.Point{}) {
+			t.Errorf("%s: path %v, dir %v; want %v and no dir", h.Word, h.Path, h.Dir, want[h.Word])
+		}
+	}
+
+	// straight words are bent words too
+	straight := Search(New("XMAS"), grid("XMAS"), Options{Bent: true})
+	if len(straight) != 1 {
+		t.Errorf("found %d straight XMASes with bent on, want 1", len(straight))
+	}
+}
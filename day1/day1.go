@@ -1,37 +1,44 @@
 package day1
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"iter"
 	"log/slog"
-	"math"
 	"os"
-	"sort"
-	"strconv"
-	"strings"
+	"slices"
 
 	"go.coldcutz.net/advent2024/common"
+	"go.coldcutz.net/advent2024/common/extsort"
+	"go.coldcutz.net/advent2024/common/parse"
 )
 
 var Solutions = common.Solutions{
 	1: Part1,
 	2: Part2,
+	3: Compare,
 }
 
 func Part1(ctx context.Context, log *slog.Logger, opts common.Opts) error {
-	left, right, err := readInts(opts)
+	cols, err := pairColumns(opts)
 	if err != nil {
 		return err
 	}
-	sort.Ints(left)
-	sort.Ints(right)
+	sorted, _, err := sortColumns(opts, log, cols)
+	if err != nil {
+		return err
+	}
+	defer closeAll(sorted)
 
-	sum := 0
-	for i, l := range left {
-		r := right[i]
-		distance := math.Abs(float64(r - l))
-		sum += int(distance)
+	var sum int
+	if opts.Explain != "" {
+		sum, err = explainDistance(opts, sorted[0], sorted[1])
+	} else {
+		sum, err = l1(sorted[0], sorted[1])
+	}
+	if err != nil {
+		return err
 	}
 
 	log.Info("result", "sum", sum)
@@ -40,57 +47,293 @@ func Part1(ctx context.Context, log *slog.Logger, opts common.Opts) error {
 }
 
 func Part2(ctx context.Context, log *slog.Logger, opts common.Opts) error {
-	left, right, err := readInts(opts)
+	cols, err := pairColumns(opts)
 	if err != nil {
 		return err
 	}
 
-	rightCounts := map[int]int{}
-	for _, r := range right {
-		rightCounts[r]++
+	var similarity int
+	switch {
+	case opts.Explain != "":
+		similarity, err = similarityExplained(opts, log, cols)
+	case fitsInMemory(opts):
+		similarity, err = similarityCounted(opts, cols)
+	default:
+		similarity, err = similaritySorted(opts, log, cols)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Info("result", "similarity", similarity)
+
+	return nil
+}
+
+// Compare computes each --metric (all of them by default) between every pair of --columns (all of them by default).
+func Compare(ctx context.Context, log *slog.Logger, opts common.Opts) error {
+	cols, err := selectedColumns(opts)
+	if err != nil {
+		return err
+	}
+	names := opts.Metrics
+	if len(names) == 0 {
+		names = MetricNames
+	}
+	for _, name := range names {
+		if _, ok := Metrics[name]; !ok {
+			return fmt.Errorf("unknown metric %q", name)
+		}
+	}
+
+	sorted, cols, err := sortColumns(opts, log, cols)
+	if err != nil {
+		return err
+	}
+	defer closeAll(sorted)
+
+	for i := range sorted {
+		for j := i + 1; j < len(sorted); j++ {
+			attrs := []any{"columns", fmt.Sprintf("%d,%d", cols[i]+1, cols[j]+1)}
+			for _, name := range names {
+				v, err := Metrics[name](sorted[i], sorted[j])
+				if err != nil {
+					return fmt.Errorf("%s of columns %d and %d: %w", name, cols[i]+1, cols[j]+1, err)
+				}
+				attrs = append(attrs, name, v)
+			}
+			log.Info("result", attrs...)
+		}
+	}
+
+	return nil
+}
+
+// selectedColumns is --columns as 0-based indices, or nil for all of them
+func selectedColumns(opts common.Opts) ([]int, error) {
+	if opts.Columns == "" {
+		return nil, nil
+	}
+	cols, err := parse.IntList(opts.Columns, ",")
+	if err != nil {
+		return nil, fmt.Errorf("invalid --columns: %w", err)
+	}
+	for i, c := range cols {
+		if c < 1 {
+			return nil, fmt.Errorf("invalid --columns: columns start at 1")
+		}
+		if slices.Contains(cols[:i], c) {
+			return nil, fmt.Errorf("invalid --columns: column %d is repeated", c)
+		}
+		cols[i] = c - 1
+	}
+	return cols, nil
+}
+
+// pairColumns is the two columns that parts 1 and 2 compare: the first two of --columns, or of the input
+func pairColumns(opts common.Opts) ([]int, error) {
+	cols, err := selectedColumns(opts)
+	if err != nil {
+		return nil, err
+	}
+	if cols == nil {
+		return []int{0, 1}, nil
+	}
+	if len(cols) < 2 {
+		return nil, fmt.Errorf("need two --columns to compare")
+	}
+	return cols[:2], nil
+}
+
+// counting costs up to about this many bytes per distinct value (a map entry plus its share of the table, which
+// briefly doubles as it grows), and each value takes at least this many bytes of input (a digit and a separator)
+const (
+	bytesPerCount = 64
+	minValueText  = 2
+)
+
+// fitsInMemory guesses from the input's size whether counting its values could blow the memory budget, assuming
+// the worst case of every value being distinct and as short as possible.
+func fitsInMemory(opts common.Opts) bool {
+	if opts.MemLimit <= 0 {
+		return true
+	}
+	fi, err := os.Stat(opts.Input)
+	if err != nil {
+		return true // let opening it report the problem
+	}
+	return fi.Size()/minValueText*bytesPerCount <= memLimit(opts)
+}
+
+func memLimit(opts common.Opts) int64 {
+	return int64(opts.MemLimit) << 20
+}
+
+func similarityCounted(opts common.Opts, cols []int) (int, error) {
+	// only the counts matter here, so this runs in memory proportional to the number of distinct values
+	leftCounts, rightCounts := common.Counter[int]{}, common.Counter[int]{}
+	err := eachRow(opts, func(row []int) error {
+		if err := checkColumns(row, cols); err != nil {
+			return err
+		}
+		leftCounts.Add(row[cols[0]])
+		rightCounts.Add(row[cols[1]])
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
 	similarity := 0
-	for _, l := range left {
-		similarity += l * rightCounts[l]
+	for l, n := range leftCounts.All() {
+		similarity += l * n * rightCounts.Count(l)
 	}
+	return similarity, nil
+}
 
-	log.Info("result", "similarity", similarity)
+func similaritySorted(opts common.Opts, log *slog.Logger, cols []int) (int, error) {
+	sorted, _, err := sortColumns(opts, log, cols)
+	if err != nil {
+		return 0, err
+	}
+	defer closeAll(sorted)
+	return similarity(sorted[0], sorted[1])
+}
+
+// sortColumns reads the given columns (all of them if cols is nil) into sorters that share the memory budget,
+// spilling to disk if they must. It returns the sorters and the columns they're for.
+func sortColumns(opts common.Opts, log *slog.Logger, cols []int) ([]*extsort.Sorter[int], []int, error) {
+	var sorted []*extsort.Sorter[int]
+	start := func() {
+		for range cols {
+			sorted = append(sorted, extsort.New[int](memLimit(opts)/int64(len(cols))))
+		}
+	}
+	err := eachRow(opts, func(row []int) error {
+		if sorted == nil {
+			if cols == nil {
+				cols = make([]int, len(row))
+				for i := range cols {
+					cols[i] = i
+				}
+			}
+			start()
+		}
+		if err := checkColumns(row, cols); err != nil {
+			return err
+		}
+		var errs []error
+		for i, c := range cols {
+			errs = append(errs, sorted[i].Add(row[c]))
+		}
+		return errors.Join(errs...)
+	})
+	if err != nil {
+		return nil, nil, errors.Join(err, closeAll(sorted))
+	}
+	if sorted == nil {
+		if cols == nil {
+			return nil, nil, fmt.Errorf("no input to take columns from")
+		}
+		start()
+	}
+	if sorted[0].Runs() > 0 {
+		log.Debug("sorting on disk", "lines", sorted[0].Len(), "runs", sorted[0].Runs())
+	}
+	return sorted, cols, nil
+}
 
+func checkColumns(row, cols []int) error {
+	for _, c := range cols {
+		if c >= len(row) {
+			return fmt.Errorf("no column %d: input has %d", c+1, len(row))
+		}
+	}
 	return nil
 }
 
-func readInts(opts common.Opts) ([]int, []int, error) {
-	f, err := os.Open(opts.Input)
+func closeAll(sorted []*extsort.Sorter[int]) error {
+	var errs []error
+	for _, s := range sorted {
+		errs = append(errs, s.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// zipSorted calls fn with the columns' values paired up in sorted order
+func zipSorted(left, right *extsort.Sorter[int], fn func(l, r int)) error {
+	ls, lsErr := left.Sorted()
+	rs, rsErr := right.Sorted()
+	nextR, stop := iter.Pull(rs)
+	defer stop()
+	for l := range ls {
+		r, _ := nextR()
+		fn(l, r)
+	}
+	stop()
+	return errors.Join(lsErr(), rsErr())
+}
+
+// mergeJoin calls fn once for each distinct value in left, with how many times it's in each column. It needs no
+// memory per distinct value.
+func mergeJoin(left, right *extsort.Sorter[int], fn func(v, nl, nr int)) error {
+	ls, lsErr := left.Sorted()
+	rs, rsErr := right.Sorted()
+	nextL, stopL := iter.Pull(ls)
+	defer stopL()
+	nextR, stopR := iter.Pull(rs)
+	defer stopR()
+
+	l, lok := nextL()
+	r, rok := nextR()
+	for lok {
+		v, nl := l, 0
+		for lok && l == v {
+			nl++
+			l, lok = nextL()
+		}
+		for rok && r < v {
+			r, rok = nextR()
+		}
+		nr := 0
+		for rok && r == v {
+			nr++
+			r, rok = nextR()
+		}
+		fn(v, nl, nr)
+	}
+	stopL()
+	stopR()
+	return errors.Join(lsErr(), rsErr())
+}
+
+// eachRow streams the input a line at a time. Every line must have as many columns as the first.
+func eachRow(opts common.Opts, fn func(row []int) error) error {
+	f, err := common.OpenInput(opts)
 	if err != nil {
-		return nil, nil, err
+		return err
 	}
 	defer f.Close()
 
-	left, right := []int{}, []int{}
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := scanner.Text()
+	width := 0
+	lines, linesErr := common.Lines(f)
+	for lineNo, line := range lines {
 		if line == "" {
 			continue
 		}
-		parts := strings.Fields(line)
-		if len(parts) != 2 {
-			return nil, nil, fmt.Errorf("invalid line: %s", line)
+		var row []int
+		if width == 0 {
+			row, err = parse.IntList(line, "")
+			width = len(row)
+		} else {
+			row, err = parse.Row(line, width)
 		}
-		li, err := strconv.Atoi(parts[0])
 		if err != nil {
-			return nil, nil, fmt.Errorf("invalid line: %s", line)
+			return parse.AtLine(lineNo, err)
 		}
-		ri, err := strconv.Atoi(parts[1])
-		if err != nil {
-			return nil, nil, fmt.Errorf("invalid line: %s", line)
+		if err := fn(row); err != nil {
+			return parse.AtLine(lineNo, err)
 		}
-		left = append(left, li)
-		right = append(right, ri)
-	}
-	if err := scanner.Err(); err != nil {
-		return nil, nil, err
 	}
-	return left, right, nil
+	return linesErr()
 }
@@ -0,0 +1,88 @@
+package day1
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+
+	"go.coldcutz.net/advent2024/common"
+	"go.coldcutz.net/advent2024/common/extsort"
+	"go.coldcutz.net/advent2024/common/numth"
+	"go.coldcutz.net/advent2024/common/report"
+)
+
+// explaining holds every row in memory, so it's for checking small inputs by hand, not for huge ones
+
+func similarityExplained(opts common.Opts, log *slog.Logger, cols []int) (int, error) {
+	sorted, _, err := sortColumns(opts, log, cols)
+	if err != nil {
+		return 0, err
+	}
+	defer closeAll(sorted)
+	return explainSimilarity(opts, sorted[0], sorted[1])
+}
+
+// explainDistance prints the pairing of the sorted columns and returns the total distance
+func explainDistance(opts common.Opts, left, right *extsort.Sorter[int]) (int, error) {
+	var rows [][]int
+	err := zipSorted(left, right, func(l, r int) {
+		rows = append(rows, []int{l, r, numth.Abs(r - l)})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return explainRows(opts, report.Table{Title: "pairs", Header: []string{"left", "right", "distance"}}, rows)
+}
+
+// explainSimilarity prints what each left value contributes to the similarity score and returns the score
+func explainSimilarity(opts common.Opts, left, right *extsort.Sorter[int]) (int, error) {
+	var lefts []int
+	rightCounts := common.Counter[int]{}
+	err := zipSorted(left, right, func(l, r int) {
+		lefts = append(lefts, l)
+		rightCounts.Add(r)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	rows := make([][]int, len(lefts))
+	for i, l := range lefts {
+		n := rightCounts.Count(l)
+		rows[i] = []int{l, n, l * n}
+	}
+	return explainRows(opts, report.Table{Title: "contributions", Header: []string{"value", "count in right", "similarity"}}, rows)
+}
+
+// explainRows writes table with a total of the last column and the top opts.Top rows by that column, returning
+// the total
+func explainRows(opts common.Opts, table report.Table, rows [][]int) (int, error) {
+	last := len(table.Header) - 1
+	total := 0
+	for _, row := range rows {
+		table.AddRow(anys(row)...)
+		total += row[last]
+	}
+
+	totals := report.Table{Title: "total", Header: []string{table.Header[last]}}
+	totals.AddRow(total)
+
+	top := report.Table{Title: fmt.Sprintf("top %d by %s", opts.Top, table.Header[last]), Header: append([]string{"rank"}, table.Header...)}
+	byLast := slices.Clone(rows)
+	slices.SortStableFunc(byLast, func(a, b []int) int { return cmp.Compare(b[last], a[last]) })
+	for i, row := range byLast[:min(opts.Top, len(byLast))] {
+		top.AddRow(append([]any{i + 1}, anys(row)...)...)
+	}
+
+	return total, report.Write(os.Stdout, opts.Explain, table, totals, top)
+}
+
+func anys(row []int) []any {
+	out := make([]any, len(row))
+	for i, v := range row {
+		out[i] = v
+	}
+	return out
+}
@@ -0,0 +1,29 @@
+package day1
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand/v2"
+)
+
+// Generate writes size lines of two location IDs. Some right-hand IDs are copied from the left so that Part2 has
+// something to find.
+func Generate(w io.Writer, size int, rng *rand.Rand) error {
+	bw := bufio.NewWriter(w)
+	left := make([]int, 0, min(size, 1<<16))
+	for range size {
+		l := 10000 + rng.IntN(90000)
+		r := 10000 + rng.IntN(90000)
+		if len(left) > 0 && rng.IntN(4) == 0 {
+			r = left[rng.IntN(len(left))]
+		}
+		if len(left) < cap(left) {
+			left = append(left, l)
+		}
+		if _, err := fmt.Fprintf(bw, "%d   %d\n", l, r); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,79 @@
+package day1
+
+import (
+	"math"
+
+	"go.coldcutz.net/advent2024/common/extsort"
+	"go.coldcutz.net/advent2024/common/numth"
+)
+
+// Metric compares two columns, each already sorted. Columns always have the same length.
+type Metric func(a, b *extsort.Sorter[int]) (any, error)
+
+// Metrics are the comparisons part 3 can make, by --metric name.
+var Metrics = map[string]Metric{
+	"l1":           wrap(l1),
+	"l2":           l2,
+	"intersection": wrap(intersection),
+	"jaccard":      jaccard,
+	"similarity":   wrap(similarity),
+}
+
+// MetricNames is the order part 3 reports metrics in.
+var MetricNames = []string{"l1", "l2", "intersection", "jaccard", "similarity"}
+
+func wrap(m func(a, b *extsort.Sorter[int]) (int, error)) Metric {
+	return func(a, b *extsort.Sorter[int]) (any, error) {
+		return m(a, b)
+	}
+}
+
+// l1 is the total distance between the columns' values paired up in sorted order (part 1's answer)
+func l1(a, b *extsort.Sorter[int]) (int, error) {
+	sum := 0
+	err := zipSorted(a, b, func(l, r int) {
+		sum += numth.Abs(r - l)
+	})
+	return sum, err
+}
+
+// l2 is the euclidean distance between the sorted columns
+func l2(a, b *extsort.Sorter[int]) (any, error) {
+	sum := 0.0
+	err := zipSorted(a, b, func(l, r int) {
+		d := float64(r - l)
+		sum += d * d
+	})
+	return math.Sqrt(sum), err
+}
+
+// intersection is the size of the columns' multiset intersection: each value counts as often as it's in both
+func intersection(a, b *extsort.Sorter[int]) (int, error) {
+	n := 0
+	err := mergeJoin(a, b, func(v, na, nb int) {
+		n += min(na, nb)
+	})
+	return n, err
+}
+
+// jaccard is the multiset Jaccard similarity, |A ∩ B| / |A ∪ B|
+func jaccard(a, b *extsort.Sorter[int]) (any, error) {
+	inter, err := intersection(a, b)
+	if err != nil {
+		return nil, err
+	}
+	union := a.Len() + b.Len() - inter
+	if union == 0 {
+		return 1.0, nil
+	}
+	return float64(inter) / float64(union), nil
+}
+
+// similarity is part 2's score: each value of a, times how often it's in b
+func similarity(a, b *extsort.Sorter[int]) (int, error) {
+	sum := 0
+	err := mergeJoin(a, b, func(v, na, nb int) {
+		sum += v * na * nb
+	})
+	return sum, err
+}
@@ -1,16 +1,16 @@
 package day2
 
 import (
-	"bufio"
 	"context"
-	"fmt"
+	"iter"
 	"log/slog"
-	"math"
 	"os"
 	"strconv"
 	"strings"
 
 	"go.coldcutz.net/advent2024/common"
+	"go.coldcutz.net/advent2024/common/parse"
+	"go.coldcutz.net/advent2024/common/report"
 )
 
 var Solutions = common.Solutions{
@@ -19,46 +19,45 @@ var Solutions = common.Solutions{
 }
 
 func Part1(ctx context.Context, log *slog.Logger, opts common.Opts) error {
-	reports, err := getReports(opts)
-	if err != nil {
-		return err
-	}
-
-	numSafe := 0
-	for _, report := range reports {
-		if reportIsSafe(report) {
-			numSafe++
-		}
-	}
-
-	log.Info("result", "numSafe", numSafe)
-
-	return nil
+	return countSafe(log, opts, policyFromOpts(opts, 0))
 }
 
 func Part2(ctx context.Context, log *slog.Logger, opts common.Opts) error {
-	reports, err := getReports(opts)
-	if err != nil {
-		return err
+	return countSafe(log, opts, policyFromOpts(opts, 1))
+}
+
+func countSafe(log *slog.Logger, opts common.Opts, policy SafetyPolicy) error {
+	var table *report.Table
+	if opts.Explain != "" {
+		table = &report.Table{Title: "reports", Header: []string{"report", "levels", "safe", "bad level", "reason"}}
+		if policy.Tolerance > 0 {
+			table.Header = append(table.Header, "removed")
+		}
 	}
 
-	numSafe := 0
-	for _, report := range reports {
-		if reportIsSafe(report) {
+	numSafe, n := 0, 0
+	for levels, err := range getReports(opts) {
+		if err != nil {
+			return err
+		}
+		n++
+		removed, safe := policy.Dampen(levels)
+		if safe {
 			numSafe++
-			continue
 		}
-
-	attempting:
-		// try excluding each level in the report
-		for i := 0; i < len(report); i++ {
-			reportCopy := make([]int, 0, len(report)-1)
-			reportCopy = append(reportCopy, report[:i]...)
-			reportCopy = append(reportCopy, report[i+1:]...)
-			if reportIsSafe(reportCopy) {
-				numSafe++
-				break attempting
+		if table != nil {
+			v, _ := policy.Check(levels)
+			row := []any{n, formatLevels(levels), safe, v.badLevel(), v.reason}
+			if policy.Tolerance > 0 {
+				row = append(row, formatRemoved(removed, safe))
 			}
+			table.AddRow(row...)
+		}
+	}
+
+	if table != nil {
+		if err := report.Write(os.Stdout, opts.Explain, *table); err != nil {
+			return err
 		}
 	}
 
@@ -67,57 +66,51 @@ func Part2(ctx context.Context, log *slog.Logger, opts common.Opts) error {
 	return nil
 }
 
-func reportIsSafe(report []int) bool {
-	// a report only counts as safe if both of the following are true:
-	// - The levels are either all increasing or all decreasing.
-	// - Any two adjacent levels differ by at least one and at most three.
-
-	isSafe := true
-	isIncreasing := report[0] < report[1]
-	for i := 1; i < len(report); i++ {
-		isStillIncreasing := report[i-1] < report[i]
-		if isIncreasing != isStillIncreasing {
-			isSafe = false
-			break
-		}
-
-		diff := math.Abs(float64(report[i] - report[i-1]))
-		if diff < 1 || diff > 3 {
-			isSafe = false
-			break
-		}
+func formatLevels(levels []int) string {
+	strs := make([]string, len(levels))
+	for i, l := range levels {
+		strs[i] = strconv.Itoa(l)
 	}
-	return isSafe
+	return strings.Join(strs, " ")
 }
 
-func getReports(opts common.Opts) ([][]int, error) {
-	f, err := os.Open(opts.Input)
-	if err != nil {
-		return nil, err
+func formatRemoved(removed []int, safe bool) string {
+	if !safe || len(removed) == 0 {
+		return "-"
 	}
-	defer f.Close()
-
-	var reports [][]int
+	strs := make([]string, len(removed))
+	for i, r := range removed {
+		strs[i] = strconv.Itoa(r)
+	}
+	return strings.Join(strs, ",")
+}
 
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
-			continue
+// getReports streams the input's reports. It yields a non-nil error at most once, as the last item.
+func getReports(opts common.Opts) iter.Seq2[[]int, error] {
+	return func(yield func([]int, error) bool) {
+		f, err := common.OpenInput(opts)
+		if err != nil {
+			yield(nil, err)
+			return
 		}
-		parts := strings.Fields(line)
-		report := make([]int, 0, len(parts))
-		for _, levelStr := range parts {
-			level, err := strconv.Atoi(levelStr)
+		defer f.Close()
+
+		lines, linesErr := common.Lines(f)
+		for lineNo, line := range lines {
+			if line == "" {
+				continue
+			}
+			report, err := parse.IntList(line, "")
 			if err != nil {
-				return nil, fmt.Errorf("invalid line: %s", line)
+				yield(nil, parse.AtLine(lineNo, err))
+				return
+			}
+			if !yield(report, nil) {
+				return
 			}
-			report = append(report, level)
 		}
-		reports = append(reports, report)
-	}
-	if err := scanner.Err(); err != nil {
-		return nil, err
+		if err := linesErr(); err != nil {
+			yield(nil, err)
+		}
 	}
-	return reports, nil
 }
@@ -0,0 +1,61 @@
+package day2
+
+import (
+	"bufio"
+	"io"
+	"math/rand/v2"
+	"strconv"
+)
+
+// Generate writes size reports of 5 to 8 levels. Most are monotonic with steps of 1 to 3, and some of those get
+// one or two levels broken so that both parts have unsafe reports to find.
+func Generate(w io.Writer, size int, rng *rand.Rand) error {
+	bw := bufio.NewWriter(w)
+	for range size {
+		report := genReport(rng)
+		line := make([]byte, 0, 3*len(report))
+		for i, level := range report {
+			if i > 0 {
+				line = append(line, ' ')
+			}
+			line = strconv.AppendInt(line, int64(level), 10)
+		}
+		line = append(line, '\n')
+		if _, err := bw.Write(line); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// genReport makes one random report
+func genReport(rng *rand.Rand) []int {
+	n := 5 + rng.IntN(4)
+	report := make([]int, n)
+	report[0] = 10 + rng.IntN(80)
+	dir := 1
+	if rng.IntN(2) == 0 {
+		dir = -1
+	}
+	for i := 1; i < n; i++ {
+		report[i] = report[i-1] + dir*(1+rng.IntN(3))
+	}
+	for range rng.IntN(3) {
+		// break a level: a repeat, a big jump or a change of direction
+		i := rng.IntN(n)
+		switch rng.IntN(3) {
+		case 0:
+			if i > 0 {
+				report[i] = report[i-1]
+			}
+		case 1:
+			report[i] += dir * (4 + rng.IntN(5))
+		case 2:
+			report[i] -= dir * (2 + rng.IntN(3))
+		}
+	}
+	for i := range report {
+		report[i] = max(report[i], 1)
+	}
+	return report
+}
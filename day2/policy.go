@@ -0,0 +1,229 @@
+package day2
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"go.coldcutz.net/advent2024/common"
+	"go.coldcutz.net/advent2024/common/numth"
+)
+
+// SafetyPolicy is the rules a report has to follow to be safe: its levels all increase or all decrease, by a step
+// between MinStep and MaxStep each time, after removing at most Tolerance of them.
+type SafetyPolicy struct {
+	MinStep, MaxStep int
+	// Strict forbids equal adjacent levels. When it's false, a repeated level is allowed whatever MinStep is, and
+	// the direction is set by the first pair that differs.
+	Strict    bool
+	Tolerance int
+}
+
+// policyFromOpts is the puzzle's policy, as changed by flags. tolerance is used unless --tolerance is given.
+func policyFromOpts(opts common.Opts, tolerance int) SafetyPolicy {
+	if opts.Tolerance >= 0 {
+		tolerance = opts.Tolerance
+	}
+	return SafetyPolicy{MinStep: opts.MinStep, MaxStep: opts.MaxStep, Strict: !opts.NonStrict, Tolerance: tolerance}
+}
+
+type reason int
+
+const (
+	noViolation reason = iota
+	zeroDelta
+	directionChange
+	deltaTooSmall
+	deltaTooBig
+)
+
+func (r reason) String() string {
+	switch r {
+	case noViolation:
+		return "-"
+	case zeroDelta:
+		return "zero delta"
+	case directionChange:
+		return "direction change"
+	case deltaTooSmall:
+		return "delta below min"
+	case deltaTooBig:
+		return "delta above max"
+	default:
+		return fmt.Sprintf("reason(%d)", int(r))
+	}
+}
+
+// violation is the first place a report stops being safe: the level at index differs from the one before it for
+// the given reason
+type violation struct {
+	index  int
+	reason reason
+}
+
+// badLevel is the 0-based index of the offending level, or "-" if there isn't one
+func (v violation) badLevel() string {
+	if v.reason == noViolation {
+		return "-"
+	}
+	return strconv.Itoa(v.index)
+}
+
+// step checks one pair of adjacent levels, given the report's direction (0 if it isn't set yet)
+func (p SafetyPolicy) step(delta, dir int) reason {
+	switch {
+	case delta == 0:
+		if p.Strict {
+			return zeroDelta
+		}
+		return noViolation
+	case dir != 0 && numth.Sign(delta) != dir:
+		return directionChange
+	case numth.Abs(delta) < p.MinStep:
+		return deltaTooSmall
+	case numth.Abs(delta) > p.MaxStep:
+		return deltaTooBig
+	default:
+		return noViolation
+	}
+}
+
+// Check finds the first violation in a report without removing any levels, if there is one. The direction is set
+// by the first pair of levels that differ. A pair of equal levels is a zero delta, never a change of direction.
+// Reports with fewer than two levels are safe.
+func (p SafetyPolicy) Check(levels []int) (violation, bool) {
+	return p.checkSkipping(levels, -1)
+}
+
+// checkSkipping is Check as if the level at index skip weren't there. Indices in the violation are still indices
+// into levels.
+func (p SafetyPolicy) checkSkipping(levels []int, skip int) (violation, bool) {
+	prev, dir := -1, 0
+	for i := range levels {
+		if i == skip {
+			continue
+		}
+		if prev < 0 {
+			prev = i
+			continue
+		}
+		delta := levels[i] - levels[prev]
+		if r := p.step(delta, dir); r != noViolation {
+			return violation{i, r}, false
+		}
+		if dir == 0 {
+			dir = numth.Sign(delta)
+		}
+		prev = i
+	}
+	return violation{}, true
+}
+
+// Dampen reports whether the report is safe after removing at most p.Tolerance levels, and which ones to remove:
+// as few as possible, and with a tolerance of one, the lowest index if there's a choice.
+func (p SafetyPolicy) Dampen(levels []int) ([]int, bool) {
+	switch {
+	case p.Tolerance <= 0:
+		_, safe := p.Check(levels)
+		return nil, safe
+	case p.Tolerance == 1:
+		i, safe := p.dampenOne(levels)
+		if i < 0 {
+			return nil, safe
+		}
+		return []int{i}, safe
+	default:
+		return p.dampenDP(levels)
+	}
+}
+
+// dampenOne is Dampen for a tolerance of one level. It returns the index of the level to remove, or -1 if the
+// report is safe as it is.
+//
+// If the first violation is between levels i-1 and i, removing any other level from 1 to i-2 leaves that pair
+// next to each other and still wrong; removing any level after i leaves it too. Removing level 0 is the exception,
+// since that can change the direction. So the only levels worth trying are 0, i-1 and i, and it's O(n).
+func (p SafetyPolicy) dampenOne(levels []int) (int, bool) {
+	v, safe := p.Check(levels)
+	if safe {
+		return -1, true
+	}
+	for _, i := range []int{0, v.index - 1, v.index} {
+		if _, safe := p.checkSkipping(levels, i); safe {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// dampenOneBrute is the obvious version of dampenOne, trying to remove every level in turn. It's O(n²) and
+// allocates for every attempt, and is kept to check dampenOne against.
+func (p SafetyPolicy) dampenOneBrute(levels []int) (int, bool) {
+	if _, safe := p.Check(levels); safe {
+		return -1, true
+	}
+
+	// try excluding each level in the report
+	for i := 0; i < len(levels); i++ {
+		levelsCopy := make([]int, 0, len(levels)-1)
+		levelsCopy = append(levelsCopy, levels[:i]...)
+		levelsCopy = append(levelsCopy, levels[i+1:]...)
+		if _, safe := p.Check(levelsCopy); safe {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// dampenDP finds the fewest levels to remove for any tolerance, in O(n * tolerance) for each direction.
+//
+// For a direction, cost[i] is the fewest removals that leave a safe run of kept levels ending with level i. Level i
+// can follow kept level j if the pair is a valid step, having removed everything between them, so
+// cost[i] = min(i, min over j of cost[j] + i-j-1), and only j within tolerance+1 of i can be cheap enough.
+func (p SafetyPolicy) dampenDP(levels []int) ([]int, bool) {
+	n := len(levels)
+	if n < 2 {
+		return nil, true
+	}
+	if _, safe := p.Check(levels); safe {
+		return nil, true
+	}
+
+	k := p.Tolerance
+	var best []int
+	cost, from := make([]int, n), make([]int, n)
+	for _, dir := range []int{1, -1} {
+		for i := range n {
+			cost[i], from[i] = i, -1 // keep nothing before i
+			for j := max(0, i-k-1); j < i; j++ {
+				if p.step(levels[i]-levels[j], dir) != noViolation {
+					continue
+				}
+				if c := cost[j] + i - j - 1; c < cost[i] {
+					cost[i], from[i] = c, j
+				}
+			}
+		}
+
+		// keep nothing after the last kept level
+		for last := n - 1; last >= max(0, n-1-k); last-- {
+			total := cost[last] + n - 1 - last
+			if total > k {
+				continue
+			}
+			var removed []int
+			for i, j := n-1, last; i >= 0; i-- {
+				if i == j {
+					j = from[j]
+					continue
+				}
+				removed = append(removed, i)
+			}
+			slices.Reverse(removed)
+			if best == nil || len(removed) < len(best) {
+				best = removed
+			}
+		}
+	}
+	return best, best != nil
+}
@@ -0,0 +1,147 @@
+package day2
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"go.coldcutz.net/advent2024/common/parse"
+)
+
+// testReports is a mix of Generate's reports and short ones of small values, where equal levels and steps at the
+// edges of the allowed range are common
+func testReports(t testing.TB, rng *rand.Rand, n int) [][]int {
+	var buf bytes.Buffer
+	if err := Generate(&buf, n, rng); err != nil {
+		t.Fatal(err)
+	}
+	var reports [][]int
+	for i, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		levels, err := parse.IntList(line, "")
+		if err != nil {
+			t.Fatal(parse.AtLine(i+1, err))
+		}
+		reports = append(reports, levels)
+	}
+	for range n {
+		levels := make([]int, rng.IntN(8))
+		for i := range levels {
+			levels[i] = rng.IntN(6)
+		}
+		reports = append(reports, levels)
+	}
+	return reports
+}
+
+func randomPolicy(rng *rand.Rand, tolerance int) SafetyPolicy {
+	minStep := rng.IntN(3)
+	return SafetyPolicy{MinStep: minStep, MaxStep: minStep + rng.IntN(4), Strict: rng.IntN(2) == 0, Tolerance: tolerance}
+}
+
+func TestDampenOneMatchesBrute(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 20 {
+		p := randomPolicy(rng, 1)
+		for _, levels := range testReports(t, rng, 2000) {
+			gotI, gotSafe := p.dampenOne(levels)
+			wantI, wantSafe := p.dampenOneBrute(levels)
+			if gotI != wantI || gotSafe != wantSafe {
+				t.Fatalf("%+v %v: dampenOne = %d, %t; brute force = %d, %t", p, levels, gotI, gotSafe, wantI, wantSafe)
+			}
+		}
+	}
+}
+
+func benchmarkDampen(b *testing.B, dampen func(SafetyPolicy, []int) (int, bool)) {
+	p := SafetyPolicy{MinStep: 1, MaxStep: 3, Strict: true, Tolerance: 1}
+	b.Run("generated", func(b *testing.B) {
+		reports := testReports(b, rand.New(rand.NewPCG(1, 2)), 1000)
+		b.ResetTimer()
+		for i := range b.N {
+			dampen(p, reports[i%len(reports)])
+		}
+	})
+	for _, n := range []int{100, 1000, 5000} {
+		b.Run(fmt.Sprintf("levels=%d", n), func(b *testing.B) {
+			levels := longReport(rand.New(rand.NewPCG(3, 4)), n)
+			for range b.N {
+				dampen(p, levels)
+			}
+		})
+	}
+}
+
+// longReport is n increasing levels with one bad jump near the end, so the brute force has to try removing
+// almost every level before it finds the one that works
+func longReport(rng *rand.Rand, n int) []int {
+	levels := make([]int, n)
+	for i := 1; i < n; i++ {
+		levels[i] = levels[i-1] + 1 + rng.IntN(3)
+	}
+	levels[n-2] += 10
+	return levels
+}
+
+func BenchmarkDampenOne(b *testing.B) {
+	benchmarkDampen(b, SafetyPolicy.dampenOne)
+}
+
+func BenchmarkDampenOneBrute(b *testing.B) {
+	benchmarkDampen(b, SafetyPolicy.dampenOneBrute)
+}
+
+// fewestRemovals tries removing every subset of up to p.Tolerance levels, smallest first, and returns how many it
+// took, or -1 if none works
+func fewestRemovals(p SafetyPolicy, levels []int) int {
+	var try func(start, left int, removed []int) bool
+	try = func(start, left int, removed []int) bool {
+		if left == 0 {
+			_, safe := p.Check(without(levels, removed))
+			return safe
+		}
+		for i := start; i < len(levels); i++ {
+			if try(i+1, left-1, append(removed, i)) {
+				return true
+			}
+		}
+		return false
+	}
+	for k := 0; k <= p.Tolerance; k++ {
+		if try(0, k, nil) {
+			return k
+		}
+	}
+	return -1
+}
+
+// without is levels minus the given (sorted) indices
+func without(levels, removed []int) []int {
+	kept := make([]int, 0, len(levels))
+	for i, l := range levels {
+		if len(removed) > 0 && removed[0] == i {
+			removed = removed[1:]
+			continue
+		}
+		kept = append(kept, l)
+	}
+	return kept
+}
+
+func TestDampenDPMatchesSubsets(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	for range 30 {
+		p := randomPolicy(rng, 2+rng.IntN(2))
+		for _, levels := range testReports(t, rng, 1000) {
+			removed, safe := p.dampenDP(levels)
+			want := fewestRemovals(p, levels)
+			if safe != (want >= 0) || (safe && len(removed) != want) {
+				t.Fatalf("%+v %v: dampenDP removed %v, %t; want %d removals", p, levels, removed, safe, want)
+			}
+			if _, ok := p.Check(without(levels, removed)); safe && !ok {
+				t.Fatalf("%+v %v: removing %v leaves an unsafe report", p, levels, removed)
+			}
+		}
+	}
+}
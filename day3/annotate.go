@@ -0,0 +1,164 @@
+package day3
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// annotator writes the input back out, highlighting each instruction by what the VM did with it, with the running
+// sum after the end of each line. It's fed trace entries in order and copies the text between them from its own
+// reader, writing it out as it goes, so its memory doesn't depend on how long the lines are.
+type annotator struct {
+	w      *bufio.Writer
+	src    *bufio.Reader
+	format string // "ansi" or "html"
+
+	off     int64 // how much of src has been copied
+	acc     int
+	inLine  bool // whether anything's been written since the last line ended
+	started bool
+	err     error
+}
+
+const (
+	styleExecuted = "exec"
+	styleSkipped  = "skip"
+	styleOn       = "on"
+	styleOff      = "off"
+)
+
+var ansiStyles = map[string]string{
+	styleExecuted: "\x1b[32m",   // green
+	styleSkipped:  "\x1b[90m",   // grey
+	styleOn:       "\x1b[1;36m", // bold cyan
+	styleOff:      "\x1b[1;31m", // bold red
+	"gutter":      "\x1b[2m",
+}
+
+const htmlHeader = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><style>
+body { background: #fff; }
+pre { font: 13px monospace; }
+.gutter { color: #999; user-select: none; }
+.exec { color: #2ca02c; font-weight: bold; }
+.skip { color: #999; text-decoration: line-through; }
+.on { background: #c8f0f4; color: #117a85; }
+.off { background: #f8d0d0; color: #b02020; }
+</style></head><body><pre>
+`
+
+func newAnnotator(w io.Writer, src io.Reader, format string) *annotator {
+	return &annotator{w: bufio.NewWriter(w), src: bufio.NewReader(src), format: format}
+}
+
+func (a *annotator) entry(t TraceEntry) {
+	if a.err != nil {
+		return
+	}
+	a.copyTo(t.Start, "")
+
+	style := styleSkipped
+	switch {
+	case !t.Op.Gated && t.Enabled:
+		style = styleOn
+	case !t.Op.Gated:
+		style = styleOff
+	case t.Executed:
+		style = styleExecuted
+	}
+	a.acc = t.Acc
+	a.copyTo(t.End, style)
+}
+
+// annotateChunk is how much plain text copyTo collects before writing it out
+const annotateChunk = 4 << 10
+
+// copyTo copies src up to offset end in the given style, finishing lines as it goes
+func (a *annotator) copyTo(end int64, style string) {
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			a.styled(text.String(), style)
+			text.Reset()
+		}
+	}
+	for ; a.off < end || end < 0; a.off++ {
+		b, err := a.src.ReadByte()
+		if err == io.EOF && end < 0 {
+			break
+		}
+		if err != nil {
+			a.err = err
+			return
+		}
+		if b == '\n' {
+			flush()
+			a.endLine()
+			continue
+		}
+		text.WriteByte(b)
+		if text.Len() >= annotateChunk {
+			flush()
+		}
+	}
+	flush()
+}
+
+func (a *annotator) styled(text, style string) {
+	a.start()
+	a.inLine = true
+	switch a.format {
+	case "html":
+		text = html.EscapeString(text)
+		if style != "" {
+			text = fmt.Sprintf(`<span class="%s">%s</span>`, style, text)
+		}
+	default:
+		if style != "" {
+			text = ansiStyles[style] + text + "\x1b[0m"
+		}
+	}
+	a.w.WriteString(text)
+}
+
+// start writes the header, if the format has one, before the first output
+func (a *annotator) start() {
+	if !a.started && a.format == "html" {
+		a.w.WriteString(htmlHeader)
+	}
+	a.started = true
+}
+
+func (a *annotator) endLine() {
+	a.start()
+	gutter := fmt.Sprintf(" │ %d", a.acc)
+	switch a.format {
+	case "html":
+		fmt.Fprintf(a.w, `<span class="gutter">%s</span>`, gutter)
+	default:
+		a.w.WriteString(ansiStyles["gutter"] + gutter + "\x1b[0m")
+	}
+	a.w.WriteByte('\n')
+	a.inLine = false
+}
+
+// finish copies whatever's left after the last instruction and flushes
+func (a *annotator) finish() error {
+	if a.err != nil {
+		return a.err
+	}
+	a.copyTo(-1, "")
+	if a.err != nil {
+		return a.err
+	}
+	if a.inLine || !a.started {
+		a.endLine()
+	}
+	if a.format == "html" {
+		a.w.WriteString("</pre></body></html>\n")
+	}
+	return a.w.Flush()
+}
@@ -3,11 +3,8 @@ package day3
 import (
 	"context"
 	"fmt"
-	"io"
 	"log/slog"
 	"os"
-	"regexp"
-	"strconv"
 
 	"go.coldcutz.net/advent2024/common"
 )
@@ -17,104 +14,96 @@ var Solutions = common.Solutions{
 	2: Part2,
 }
 
+// longest instruction we expect to see; a candidate that gets this long without finishing is a near miss
+const maxInstructionLen = 1024
+
+var (
+	mul = Opcode{Name: "mul", Arity: 2, Operands: Operands{MinDigits: 1, MaxDigits: 3}, Gated: true, Exec: func(vm *VM, args []int) {
+		vm.Acc += args[0] * args[1]
+	}}
+	do = Opcode{Name: "do", Exec: func(vm *VM, args []int) {
+		vm.Enabled = true
+	}}
+	dont = Opcode{Name: "don't", Exec: func(vm *VM, args []int) {
+		vm.Enabled = false
+	}}
+
+	part1Set = MustInstructionSet(mul)
+	// with `do()` and `don't()`
+	// do enables stuff and dont disables it
+	part2Set = MustInstructionSet(mul, do, dont)
+)
+
 // aka `cat day3/input-1.txt | grep -oE 'mul\([0-9]+,[0-9]+\)' | sed -e 's/mul(//' -e 's/)$//' -e 's/,/*/' | paste -sd+ - | bc“
 func Part1(ctx context.Context, log *slog.Logger, opts common.Opts) error {
-	f, err := os.Open(opts.Input)
+	return run(log, opts, part1Set)
+}
+
+func Part2(ctx context.Context, log *slog.Logger, opts common.Opts) error {
+	return run(log, opts, part2Set)
+}
+
+func run(log *slog.Logger, opts common.Opts, set *InstructionSet) error {
+	f, err := common.OpenInput(opts)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	cnt, err := io.ReadAll(f)
-	if err != nil {
-		return err
+	// the operand flags replace each opcode's own rules, but only if any of them was changed
+	if operands := operandFlags(opts); operands != operandFlags(common.DefaultOpts()) {
+		set = set.WithOperands(operands)
 	}
 
-	getMultsRx := regexp.MustCompile(`mul\([0-9]+,[0-9]+\)`)
-
-	ms := getMultsRx.FindAll(cnt, -1)
+	misses := 0
+	onMiss := func(m NearMiss) {
+		misses++
+		if opts.NearMisses {
+			log.Info("near miss", "offset", m.Start, "text", m.Text, "reason", m.Err)
+		}
+	}
 
-	sum := 0
-	for _, mult := range ms {
-		product, err := parseMult(mult)
+	var traces []func(TraceEntry)
+	if opts.Trace {
+		traces = append(traces, func(t TraceEntry) { fmt.Println(t) })
+	}
+	var ann *annotator
+	if opts.Annotate != "" {
+		// the annotation reads its own copy of the input, in step with the VM, so it streams too
+		src, err := common.OpenInput(opts)
 		if err != nil {
 			return err
 		}
-		sum += product
-
+		defer src.Close()
+		ann = newAnnotator(os.Stdout, src, opts.Annotate)
+		traces = append(traces, ann.entry)
 	}
-	log.Info("result", "sum", sum)
-
-	return nil
-}
-
-func Part2(ctx context.Context, log *slog.Logger, opts common.Opts) error {
-	// with `do()` and `don't()`
-	// do enables stuff and dont disables it
 
-	cnt, err := common.ReadAllInput(opts)
-	if err != nil {
+	vm := NewVM()
+	if len(traces) > 0 {
+		vm.Trace = func(t TraceEntry) {
+			for _, trace := range traces {
+				trace(t)
+			}
+		}
+	}
+	instrs, instrsErr := Tokenize(set, f, onMiss)
+	vm.Run(instrs)
+	if err := instrsErr(); err != nil {
 		return err
 	}
-
-	getMultsRx := regexp.MustCompile(`mul\([0-9]+,[0-9]+\)`)
-	multOrDoOrDontRx := regexp.MustCompile(fmt.Sprintf(`%s|do\(\)|don't\(\)`, getMultsRx.String()))
-	fmt.Printf("multOrDoOrDontRx: %v\n", multOrDoOrDontRx)
-
-	sum := 0
-	doing := true
-	for pos := 0; pos < len(cnt); pos++ {
-		nextThingIdx := multOrDoOrDontRx.FindIndex(cnt[pos:])
-		if nextThingIdx == nil {
-			break
-		}
-		pos += nextThingIdx[0]
-
-		nextThing := multOrDoOrDontRx.Find(cnt[pos:])
-
-		if nextThing == nil {
-			break
-		}
-		if string(nextThing) == "do()" {
-			doing = true
-			continue
-		} else if string(nextThing) == "don't()" {
-			doing = false
-			continue
-		}
-
-		if !doing {
-			continue
-		}
-
-		product, err := parseMult(nextThing)
-		if err != nil {
+	if ann != nil {
+		if err := ann.finish(); err != nil {
 			return err
 		}
-		sum += product
 	}
 
-	log.Info("result", "sum", sum)
+	log.Debug("ran", "executed", vm.Executed, "skipped", vm.Skipped, "nearMisses", misses)
+	log.Info("result", "sum", vm.Acc)
 
 	return nil
 }
 
-func parseMult(mult []byte) (int, error) {
-	getOperandsRx := regexp.MustCompile(`[0-9]+`)
-
-	operands := getOperandsRx.FindAll(mult, -1)
-	if len(operands) != 2 {
-		return 0, fmt.Errorf("invalid operands: %v", operands)
-	}
-
-	left, err := strconv.Atoi(string(operands[0]))
-	if err != nil {
-		return 0, err
-	}
-
-	right, err := strconv.Atoi(string(operands[1]))
-	if err != nil {
-		return 0, err
-	}
-	return left * right, nil
+func operandFlags(opts common.Opts) Operands {
+	return Operands{MinDigits: opts.MinDigits, MaxDigits: opts.MaxDigits, Signed: opts.Signed, Spaces: opts.Spaces}
 }
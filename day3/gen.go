@@ -0,0 +1,43 @@
+package day3
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand/v2"
+)
+
+// near misses that a sloppy parser might accept
+var noise = []string{
+	"mul(4*", "mul(6,9!", "?(12,34)", "mul ( 2 , 4 )", "mul[3,7]", "do_not_mul(5,5)", "don't", "do(", "mul(1234,5)",
+	"select()", "how()", "who()", "where()", "from()", "what()", "why()", "when()", "+", "'", "[", "]", "{", "}",
+	"<", ">", "%", "&", "^", "@", "#", "$", "~", "!", ",", ")", "(", " ", "\n",
+}
+
+// Generate writes size instructions (valid muls, do()s and don't()s) buried in corrupted memory.
+func Generate(w io.Writer, size int, rng *rand.Rand) error {
+	bw := bufio.NewWriter(w)
+	for range size {
+		for range rng.IntN(4) {
+			if _, err := bw.WriteString(noise[rng.IntN(len(noise))]); err != nil {
+				return err
+			}
+		}
+		var err error
+		switch n := rng.IntN(10); {
+		case n == 0:
+			_, err = bw.WriteString("do()")
+		case n == 1:
+			_, err = bw.WriteString("don't()")
+		default:
+			_, err = fmt.Fprintf(bw, "mul(%d,%d)", rng.IntN(1000), rng.IntN(1000))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := bw.WriteString("\n"); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
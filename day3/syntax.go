@@ -0,0 +1,202 @@
+package day3
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Operands says what an opcode's operands may look like.
+type Operands struct {
+	// each operand has MinDigits to MaxDigits digits; MaxDigits <= 0 means no limit
+	MinDigits, MaxDigits int
+	// Signed allows a leading + or -
+	Signed bool
+	// Spaces allows spaces and tabs around the parentheses and commas, eg `mul ( 2 , 4 )`
+	Spaces bool
+}
+
+// Instruction is one instruction recovered from the input, and the bytes [Start, End) it came from.
+type Instruction struct {
+	Op         *Opcode
+	Args       []int
+	Start, End int64
+}
+
+func (in Instruction) String() string {
+	args := make([]string, len(in.Args))
+	for i, a := range in.Args {
+		args[i] = strconv.Itoa(a)
+	}
+	return in.Op.Name + "(" + strings.Join(args, ",") + ")"
+}
+
+// NearMiss is an opcode's name followed by something that isn't a valid instruction, eg `mul(4*` or
+// `mul(1234,5)`. Text runs from the name up to and including the byte that gave it away.
+type NearMiss struct {
+	Start, End int64
+	Text       string
+	Err        error
+}
+
+var errEOF = errors.New("unexpected end of input")
+
+type phase int
+
+const (
+	inName phase = iota
+	beforeOpen
+	beforeOperand
+	inOperand
+	afterOperand
+	matched
+)
+
+// matcher recognizes one opcode's instructions a byte at a time
+type matcher struct {
+	op     *Opcode
+	phase  phase
+	i      int // bytes of the name matched so far
+	digits int
+	neg    bool
+	v      int
+	args   []int
+}
+
+func newMatcher(op *Opcode) matcher {
+	return matcher{op: op}
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t'
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+func isLetter(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// step feeds the matcher the next byte. It returns true once the instruction is complete, or an error if b can't
+// continue it.
+func (m *matcher) step(b byte) (bool, error) {
+	o := m.op.Operands
+	switch m.phase {
+	case inName:
+		if b != m.op.Name[m.i] {
+			return false, fmt.Errorf("not %s", m.op.Name)
+		}
+		m.i++
+		if m.i == len(m.op.Name) {
+			m.phase = beforeOpen
+		}
+		return false, nil
+
+	case beforeOpen:
+		switch {
+		case b == '(':
+			m.phase = beforeOperand
+			return false, nil
+		case isSpace(b) && o.Spaces:
+			return false, nil
+		}
+		return false, fmt.Errorf("expected '(' after %s, got %q", m.op.Name, b)
+
+	case beforeOperand:
+		switch {
+		case isSpace(b) && o.Spaces:
+			return false, nil
+		case b == ')' && len(m.args) == 0 && m.op.Arity == 0:
+			m.phase = matched
+			return true, nil
+		case b == ')' && len(m.args) == 0:
+			return false, fmt.Errorf("%s takes %d operands, got 0", m.op.Name, m.op.Arity)
+		case m.op.Arity == 0:
+			return false, fmt.Errorf("%s takes no operands, got %q", m.op.Name, b)
+		case (b == '-' || b == '+') && o.Signed:
+			m.phase, m.neg = inOperand, b == '-'
+			return false, nil
+		case b == '-' || b == '+':
+			return false, fmt.Errorf("operand %d: signs aren't allowed", len(m.args)+1)
+		case isDigit(b):
+			m.phase = inOperand
+			return m.digit(b)
+		}
+		return false, fmt.Errorf("operand %d: expected a digit, got %q", len(m.args)+1, b)
+
+	case inOperand:
+		if isDigit(b) {
+			return m.digit(b)
+		}
+		if err := m.endOperand(); err != nil {
+			return false, err
+		}
+		return m.afterOperand(b)
+
+	case afterOperand:
+		return m.afterOperand(b)
+	}
+	return false, fmt.Errorf("already matched")
+}
+
+func (m *matcher) digit(b byte) (bool, error) {
+	o := m.op.Operands
+	if o.MaxDigits > 0 && m.digits == o.MaxDigits {
+		return false, fmt.Errorf("operand %d: more than %d digits", len(m.args)+1, o.MaxDigits)
+	}
+	d := int(b - '0')
+	if m.v > (maxInt-d)/10 {
+		return false, fmt.Errorf("operand %d: too big", len(m.args)+1)
+	}
+	m.v = m.v*10 + d
+	m.digits++
+	return false, nil
+}
+
+const maxInt = int(^uint(0) >> 1)
+
+func (m *matcher) endOperand() error {
+	if m.digits < max(m.op.Operands.MinDigits, 1) {
+		if m.digits == 0 {
+			return fmt.Errorf("operand %d: no digits", len(m.args)+1)
+		}
+		return fmt.Errorf("operand %d: fewer than %d digits", len(m.args)+1, m.op.Operands.MinDigits)
+	}
+	v := m.v
+	if m.neg {
+		v = -v
+	}
+	m.args = append(m.args, v)
+	m.phase, m.digits, m.neg, m.v = afterOperand, 0, false, 0
+	return nil
+}
+
+func (m *matcher) afterOperand(b byte) (bool, error) {
+	switch {
+	case isSpace(b) && m.op.Operands.Spaces:
+		return false, nil
+	case b == ',' && len(m.args) < m.op.Arity:
+		m.phase = beforeOperand
+		return false, nil
+	case b == ',':
+		return false, fmt.Errorf("%s takes %d operands, got more", m.op.Name, m.op.Arity)
+	case b == ')' && len(m.args) == m.op.Arity:
+		m.phase = matched
+		return true, nil
+	case b == ')':
+		return false, fmt.Errorf("%s takes %d operands, got %d", m.op.Name, m.op.Arity, len(m.args))
+	}
+	if len(m.args) < m.op.Arity {
+		return false, fmt.Errorf("operand %d: expected ',', got %q", len(m.args), b)
+	}
+	return false, fmt.Errorf("operand %d: expected ')', got %q", len(m.args), b)
+}
+
+// nearMiss is whether a matcher that failed at b got far enough to be worth reporting: past the whole name, and
+// not just into a longer word
+func (m *matcher) nearMiss(b byte) bool {
+	return m.phase > beforeOpen || m.phase == beforeOpen && !isLetter(b)
+}
@@ -0,0 +1,193 @@
+package day3
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// Tokenize recovers every valid instruction from corrupted text, skipping everything else. If onMiss isn't nil,
+// it's called with every near miss. Call the returned func afterwards to check for read errors.
+//
+// It reads r once, a byte at a time, so instructions can be split across reads however they like, and it holds
+// nothing but the instructions that might still be starting, so its memory doesn't grow with the input. The
+// results are as if every offset were tried in turn: the first opcode in the set that matches there wins, the
+// search picks up again after it, and an offset where nothing matches is a near miss if one got far enough.
+func Tokenize(set *InstructionSet, r io.Reader, onMiss func(NearMiss)) (iter.Seq[Instruction], func() error) {
+	var err error
+	seq := func(yield func(Instruction) bool) {
+		t := &tokenizer{set: set}
+		br := bufio.NewReaderSize(r, 64*1024)
+		for {
+			b, rerr := br.ReadByte()
+			if rerr == io.EOF {
+				t.eof()
+				t.emit(yield, onMiss)
+				return
+			} else if rerr != nil {
+				err = rerr
+				return
+			}
+			t.feed(b)
+			if !t.emit(yield, onMiss) {
+				return
+			}
+		}
+	}
+	return seq, func() error { return err }
+}
+
+// tokenizer is the state between bytes: one group of candidates for every offset that might start an instruction
+// and hasn't been settled yet, and the bytes since the oldest of them, for near misses' text.
+type tokenizer struct {
+	set    *InstructionSet
+	groups []group
+	off    int64 // offset of the next byte
+	skip   int64 // nothing starts before here: it's inside an instruction already found
+	text   []byte
+	base   int64 // offset of text[0]
+}
+
+// group is every opcode's attempt at matching from start, in the set's order
+type group struct {
+	start int64
+	cands []candidate
+}
+
+type candidate struct {
+	m     matcher
+	state candidateState
+	end   int64 // just past the last byte looked at, once it's done or failed
+	miss  bool  // whether failing counts as a near miss
+	err   error
+}
+
+type candidateState int
+
+const (
+	alive candidateState = iota
+	done
+	failed
+)
+
+func (t *tokenizer) feed(b byte) {
+	off := t.off
+	t.off++
+	if len(t.groups) == 0 {
+		t.text, t.base = t.text[:0], off
+	}
+	t.text = append(t.text, b)
+
+	if off >= t.skip && t.set.starts(b) {
+		g := group{start: off, cands: make([]candidate, len(t.set.ops))}
+		for i, op := range t.set.ops {
+			g.cands[i].m = newMatcher(op)
+		}
+		t.groups = append(t.groups, g)
+	}
+
+	for gi := range t.groups {
+		g := &t.groups[gi]
+		for ci := range g.cands {
+			c := &g.cands[ci]
+			if c.state != alive {
+				continue
+			}
+			ok, err := c.m.step(b)
+			switch {
+			case ok:
+				c.state, c.end = done, off+1
+			case err != nil:
+				c.state, c.end, c.err, c.miss = failed, off+1, err, c.m.nearMiss(b)
+			case off+1-g.start >= maxInstructionLen:
+				c.state, c.end, c.miss = failed, off+1, true
+				c.err = fmt.Errorf("longer than %d bytes", maxInstructionLen)
+			}
+		}
+	}
+}
+
+// eof fails everything still going. Only a candidate that got past its opening parenthesis is a near miss.
+func (t *tokenizer) eof() {
+	for gi := range t.groups {
+		for ci := range t.groups[gi].cands {
+			c := &t.groups[gi].cands[ci]
+			if c.state == alive {
+				c.state, c.end, c.err, c.miss = failed, t.off, errEOF, c.m.phase > beforeOpen
+			}
+		}
+	}
+}
+
+// resolve reports what g found, once that's certain: the first opcode to match, or if none did, the near miss that
+// got furthest, if any
+func (g *group) resolve() (settled bool, instr *candidate, miss *candidate) {
+	for ci := range g.cands {
+		c := &g.cands[ci]
+		switch c.state {
+		case alive:
+			return false, nil, nil
+		case done:
+			return true, c, nil
+		}
+	}
+	for ci := range g.cands {
+		c := &g.cands[ci]
+		if c.miss && (miss == nil || c.end > miss.end) {
+			miss = c
+		}
+	}
+	return true, nil, miss
+}
+
+// emit passes on the results of settled groups, oldest first, stopping at the first one that isn't settled. It
+// returns false if yield asked to stop.
+func (t *tokenizer) emit(yield func(Instruction) bool, onMiss func(NearMiss)) bool {
+	for len(t.groups) > 0 {
+		g := &t.groups[0]
+		settled, instr, miss := g.resolve()
+		if !settled {
+			break
+		}
+		switch {
+		case instr != nil:
+			if !yield(Instruction{Op: instr.m.op, Args: instr.m.args, Start: g.start, End: instr.end}) {
+				return false
+			}
+			// anything that started inside the instruction never happened
+			t.skip = instr.end
+			n := 1
+			for n < len(t.groups) && t.groups[n].start < instr.end {
+				n++
+			}
+			t.groups = t.groups[n:]
+		case miss != nil && onMiss != nil:
+			onMiss(NearMiss{Start: g.start, End: miss.end, Text: string(t.text[g.start-t.base : miss.end-t.base]), Err: miss.err})
+			t.groups = t.groups[1:]
+		default:
+			t.groups = t.groups[1:]
+		}
+	}
+
+	// drop text that no group can need any more
+	keep := t.off
+	if len(t.groups) > 0 {
+		keep = t.groups[0].start
+	}
+	if drop := int(keep - t.base); drop > len(t.text)/2 && drop > 0 {
+		t.text = append(t.text[:0], t.text[drop:]...)
+		t.base = keep
+	}
+	return true
+}
+
+// starts is whether any opcode's name starts with b
+func (s *InstructionSet) starts(b byte) bool {
+	for _, op := range s.ops {
+		if op.Name[0] == b {
+			return true
+		}
+	}
+	return false
+}
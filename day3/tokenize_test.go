@@ -0,0 +1,185 @@
+package day3
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestTokenize(t *testing.T) {
+	spaces := Operands{MinDigits: 1, MaxDigits: 3, Spaces: true}
+	signed := Operands{MinDigits: 1, MaxDigits: 3, Signed: true}
+	tests := []struct {
+		name     string
+		set      *InstructionSet
+		operands *Operands // replaces the set's own, if not nil
+		in       string
+		want     []string
+		misses   []string
+		sum      int
+	}{
+		{
+			name:   "part 1 example",
+			set:    part1Set,
+			in:     "xmul(2,4)%&mul[3,7]!@^do_not_mul(5,5)+mul(32,64]then(mul(11,8)mul(8,5))",
+			want:   []string{"mul(2,4)", "mul(5,5)", "mul(11,8)", "mul(8,5)"},
+			misses: []string{"mul[", "mul(32,64]"},
+			sum:    161,
+		},
+		{
+			name:   "part 2 example",
+			set:    part2Set,
+			in:     "xmul(2,4)&mul[3,7]!^don't()_mul(5,5)+mul(32,64](mul(11,8)undo()?mul(8,5))",
+			want:   []string{"mul(2,4)", "don't()", "mul(5,5)", "mul(11,8)", "do()", "mul(8,5)"},
+			misses: []string{"mul[", "mul(32,64]"},
+			sum:    48,
+		},
+		{
+			name:   "bad operator",
+			set:    part1Set,
+			in:     "mul(4*",
+			misses: []string{"mul(4*"},
+		},
+		{
+			name:   "spaces not allowed",
+			set:    part1Set,
+			in:     "mul ( 2 , 4 )",
+			misses: []string{"mul "},
+		},
+		{
+			name:     "spaces allowed",
+			set:      part1Set,
+			operands: &spaces,
+			in:       "mul ( 2 , 4 )",
+			want:     []string{"mul(2,4)"},
+			sum:      8,
+		},
+		{
+			name:     "tabs allowed",
+			set:      part1Set,
+			operands: &spaces,
+			in:       "mul\t(2,\t4)",
+			want:     []string{"mul(2,4)"},
+			sum:      8,
+		},
+		{
+			name:   "too many digits",
+			set:    part1Set,
+			in:     "mul(1234,5)",
+			misses: []string{"mul(1234"},
+		},
+		{
+			name:     "no digit limit",
+			set:      part1Set,
+			operands: &Operands{MinDigits: 1},
+			in:       "mul(1234,5)",
+			want:     []string{"mul(1234,5)"},
+			sum:      6170,
+		},
+		{
+			name:     "too few digits",
+			set:      part1Set,
+			operands: &Operands{MinDigits: 2, MaxDigits: 3},
+			in:       "mul(12,3)mul(12,34)",
+			want:     []string{"mul(12,34)"},
+			misses:   []string{"mul(12,3)"},
+			sum:      408,
+		},
+		{
+			name:   "signs not allowed",
+			set:    part1Set,
+			in:     "mul(-2,4)",
+			misses: []string{"mul(-"},
+		},
+		{
+			name:     "signed",
+			set:      part1Set,
+			operands: &signed,
+			in:       "mul(-2,4)mul(+3,-1)",
+			want:     []string{"mul(-2,4)", "mul(3,-1)"},
+			sum:      -11,
+		},
+		{
+			name:   "wrong arity",
+			set:    part1Set,
+			in:     "mul(2)mul(2,3,4)mul()",
+			misses: []string{"mul(2)", "mul(2,3,", "mul()"},
+		},
+		{
+			name:   "restarts inside a failed instruction",
+			set:    part1Set,
+			in:     "mul(2,mul(3,4))mulmul(5,6)",
+			want:   []string{"mul(3,4)", "mul(5,6)"},
+			misses: []string{"mul(2,m"},
+			sum:    42,
+		},
+		{
+			name: "longer word",
+			set:  part1Set,
+			in:   "multiply(2,3)",
+		},
+		{
+			name:   "cut off",
+			set:    part1Set,
+			in:     "mul(2,3",
+			misses: []string{"mul(2,3"},
+		},
+		{
+			name: "do and don't are just text in part 1",
+			set:  part1Set,
+			in:   "don't()mul(2,3)",
+			want: []string{"mul(2,3)"},
+			sum:  6,
+		},
+		{
+			name: "disabled",
+			set:  part2Set,
+			in:   "don't()mul(2,3)do()mul(4,5)",
+			want: []string{"don't()", "mul(2,3)", "do()", "mul(4,5)"},
+			sum:  20,
+		},
+		{
+			name:     "too long",
+			set:      part1Set,
+			operands: &spaces,
+			in:       "mul(2," + strings.Repeat(" ", maxInstructionLen) + "3)",
+			misses:   []string{"mul(2," + strings.Repeat(" ", maxInstructionLen-6)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := tt.set
+			if tt.operands != nil {
+				set = set.WithOperands(*tt.operands)
+			}
+			var misses []string
+			onMiss := func(m NearMiss) {
+				misses = append(misses, m.Text)
+			}
+			instrs, instrsErr := Tokenize(set, iotest.OneByteReader(strings.NewReader(tt.in)), onMiss)
+
+			var got []string
+			vm := NewVM()
+			for in := range instrs {
+				if text := tt.in[in.Start:in.End]; !strings.HasPrefix(text, in.Op.Name) {
+					t.Errorf("%v came from %q", in, text)
+				}
+				got = append(got, in.String())
+				vm.Step(in)
+			}
+			if err := instrsErr(); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("instructions = %q, want %q", got, tt.want)
+			}
+			if !slices.Equal(misses, tt.misses) {
+				t.Errorf("near misses = %q, want %q", misses, tt.misses)
+			}
+			if vm.Acc != tt.sum {
+				t.Errorf("sum = %d, want %d", vm.Acc, tt.sum)
+			}
+		})
+	}
+}
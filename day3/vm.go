@@ -0,0 +1,122 @@
+package day3
+
+import (
+	"fmt"
+	"iter"
+)
+
+// Opcode is an instruction the VM knows: `Name(a,b,...)` with exactly Arity operands.
+type Opcode struct {
+	Name     string
+	Arity    int
+	Operands Operands
+	// Gated instructions are skipped while the VM's enabled flag is clear
+	Gated bool
+	Exec  func(vm *VM, args []int)
+}
+
+// InstructionSet is a table of opcodes. Where two could match at the same place, the one registered first wins.
+type InstructionSet struct {
+	ops []*Opcode
+}
+
+// WithOperands is a copy of the set with every opcode that takes operands taking them as o says.
+func (s *InstructionSet) WithOperands(o Operands) *InstructionSet {
+	c := &InstructionSet{}
+	for _, op := range s.ops {
+		op := *op
+		if op.Arity > 0 {
+			op.Operands = o
+		}
+		c.ops = append(c.ops, &op)
+	}
+	return c
+}
+
+func NewInstructionSet(ops ...Opcode) (*InstructionSet, error) {
+	s := &InstructionSet{}
+	for _, op := range ops {
+		if err := s.Register(op); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func MustInstructionSet(ops ...Opcode) *InstructionSet {
+	s, err := NewInstructionSet(ops...)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (s *InstructionSet) Register(op Opcode) error {
+	switch {
+	case op.Name == "":
+		return fmt.Errorf("opcode has no name")
+	case op.Arity < 0:
+		return fmt.Errorf("opcode %s has a negative arity", op.Name)
+	case op.Exec == nil:
+		return fmt.Errorf("opcode %s has no Exec", op.Name)
+	}
+	for _, o := range s.ops {
+		if o.Name == op.Name {
+			return fmt.Errorf("opcode %s is already registered", op.Name)
+		}
+	}
+	s.ops = append(s.ops, &op)
+	return nil
+}
+
+// VM runs instructions. It has one register, the accumulator, and one flag, which gates instructions that ask for
+// it.
+type VM struct {
+	Acc     int
+	Enabled bool
+
+	Executed, Skipped int
+	// Trace, if set, is called after every instruction
+	Trace func(TraceEntry)
+}
+
+// TraceEntry records what happened to one instruction, and the VM's state afterwards.
+type TraceEntry struct {
+	Instruction
+	Executed bool
+	Acc      int
+	Enabled  bool
+}
+
+func (t TraceEntry) String() string {
+	verdict := "exec"
+	if !t.Executed {
+		verdict = "skip"
+	}
+	return fmt.Sprintf("%8d-%-8d %s %-16s acc=%d", t.Start, t.End, verdict, t.Instruction, t.Acc)
+}
+
+func NewVM() *VM {
+	return &VM{Enabled: true}
+}
+
+// Step executes one instruction, or skips it if it's gated and the VM is disabled.
+func (vm *VM) Step(in Instruction) {
+	executed := !in.Op.Gated || vm.Enabled
+	if executed {
+		in.Op.Exec(vm, in.Args)
+		vm.Executed++
+	} else {
+		vm.Skipped++
+	}
+	if vm.Trace != nil {
+		vm.Trace(TraceEntry{Instruction: in, Executed: executed, Acc: vm.Acc, Enabled: vm.Enabled})
+	}
+}
+
+// Run steps through every instruction.
+func (vm *VM) Run(instrs iter.Seq[Instruction]) {
+	for in := range instrs {
+		vm.Step(in)
+	}
+}
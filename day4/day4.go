@@ -2,10 +2,14 @@ package day4
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"strings"
 
 	"go.coldcutz.net/advent2024/common"
+	"go.coldcutz.net/advent2024/common/pattern"
+	"go.coldcutz.net/advent2024/common/viz"
+	"go.coldcutz.net/advent2024/common/wordsearch"
 )
 
 var Solutions = common.Solutions{
@@ -15,7 +19,10 @@ var Solutions = common.Solutions{
 
 func Part1(ctx context.Context, log *slog.Logger, opts common.Opts) error {
 	// word search
-	const needle = "XMAS"
+	words := strings.Split(opts.Words, ",")
+	if opts.Words == "" {
+		words = []string{"XMAS"}
+	}
 	cnt, err := common.ReadAllInput(opts)
 	if err != nil {
 		return err
@@ -30,51 +37,28 @@ func Part1(ctx context.Context, log *slog.Logger, opts common.Opts) error {
 		matrix = append(matrix, []rune(line))
 	}
 
-	dirs := map[string][2]int{
-		"up":         {0, -1},
-		"up-left":    {-1, -1},
-		"up-right":   {1, -1},
-		"down":       {0, 1},
-		"down-left":  {-1, 1},
-		"down-right": {1, 1},
-		"left":       {-1, 0},
-		"right":      {1, 0},
-	}
+	searcher := wordsearch.New(words...)
+	hits := wordsearch.Search(searcher, matrix, wordsearch.Options{Wrap: opts.Wrap, Bent: opts.Bent})
 
-	count := 0
-	// for each X, search in all directions
-	for y, row := range matrix {
-		xmax, ymax := len(row)-1, len(matrix)-1
-		for x, char := range row {
-			if char != rune(needle[0]) {
-				continue
-			}
-			log.Debug("found x at", "x", x, "y", y)
-			for dir, dxy := range dirs {
-				// try dir
-				match := true
-				dx, dy := dxy[0], dxy[1]
-				for i := 1; i < len(needle); i++ {
-					newx, newy := x+dx*i, y+dy*i
-					// log.Debug("checking", "dir", dir, "x", newx, "y", newy, "xmax", xmax, "ymax", ymax)
-					if newx < 0 || newx > xmax || newy < 0 || newy > ymax {
-						match = false
-						break
-					}
-					if matrix[newy][newx] != rune(needle[i]) {
-						match = false
-						break
-					}
-				}
-				if match {
-					log.Debug("found xmas", "dir", dir, "x", x, "y", y)
-					count++
-				} else {
-					log.Debug("giving up on dir", "dir", dir, "x", x, "y", y)
-				}
-			}
+	perWord := common.Counter[string]{}
+	for _, hit := range hits {
+		log.Debug("found word", "word", hit.Word, "x", hit.Start.X, "y", hit.Start.Y, "dir", hit.Dir, "end", hit.End)
+		perWord.Add(hit.Word)
+	}
+	if len(words) > 1 {
+		for _, c := range perWord.MostCommon(-1, strings.Compare) {
+			log.Info("word", "word", c.Item, "count", c.N)
 		}
 	}
+	count := len(hits)
+
+	found := []common.Point{}
+	for _, hit := range hits {
+		found = append(found, hit.Path...)
+	}
+	if err := renderFound(opts, matrix, found, fmt.Sprintf("%d words", count)); err != nil {
+		return err
+	}
 
 	log.Info("result", "count", count)
 
@@ -96,54 +80,23 @@ func Part2(ctx context.Context, log *slog.Logger, opts common.Opts) error {
 		matrix = append(matrix, []rune(line))
 	}
 
-	/// all possible permutations:
-	perms := [][][]rune{
-		{
-			[]rune("M.S"),
-			[]rune(".A."),
-			[]rune("M.S"),
-		},
-		{
-			[]rune("S.S"),
-			[]rune(".A."),
-			[]rune("M.M"),
-		},
-		{
-			[]rune("S.M"),
-			[]rune(".A."),
-			[]rune("S.M"),
-		},
-		{
-			[]rune("M.M"),
-			[]rune(".A."),
-			[]rune("S.S"),
-		},
-	}
-
-	// all patterns start with no offset and get incremented as we go
-	patterns := []pattern{}
-	for _, perm := range perms {
-		ms := []matcher{}
-		for y, row := range perm {
-			for x, r := range row {
-				ms = append(ms, matcher{x, y, r})
-			}
-		}
-		patterns = append(patterns, ms)
-	}
+	// the other three orientations are generated from this one
+	xmas := pattern.MustCompile(`
+M.S
+.A.
+M.S
+`, pattern.Rotations)
 
 	count := 0
-	for y, row := range matrix {
-		for x := range row {
-			for pi, pattern := range patterns {
-				log.Debug("checking pattern", "pattern", pi, "x", x, "y", y)
-				if pattern.match(matrix, x, y) {
-					log.Info("found match", "x", x, "y", y, "pattern", pi)
-					count++
-					break
-				}
-			}
-		}
+	found := []common.Point{}
+	for _, m := range pattern.FindAll(xmas, matrix) {
+		log.Info("found match", "x", m.At.X, "y", m.At.Y, "orientation", m.Orientation)
+		count++
+		// just the letters, not the wildcards
+		found = append(found, m.Literals...)
+	}
+	if err := renderFound(opts, matrix, found, fmt.Sprintf("%d X-MASes", count)); err != nil {
+		return err
 	}
 
 	log.Info("result", "count", count)
@@ -151,28 +104,18 @@ func Part2(ctx context.Context, log *slog.Logger, opts common.Opts) error {
 	return nil
 }
 
-type matcher struct {
-	x, y int
-	r    rune
-}
-
-func (m matcher) match(matrix [][]rune, x, y int) bool {
-	if m.x+x < 0 || m.x+x >= len(matrix[0]) || m.y+y < 0 || m.y+y >= len(matrix) {
-		return false
-	}
-	if m.r == '.' {
-		return true
+// renderFound draws the grid with the found cells highlighted, if --viz or --render asked for it
+func renderFound(opts common.Opts, matrix [][]rune, found []common.Point, caption string) error {
+	rend, err := viz.Open(opts.Viz, opts.Render, opts.FPS)
+	if err != nil || rend == nil {
+		return err
 	}
-	return matrix[m.y+y][m.x+x] == m.r
-}
-
-type pattern []matcher
-
-func (p pattern) match(matrix [][]rune, x, y int) bool {
-	for _, m := range p {
-		if !m.match(matrix, x, y) {
-			return false
-		}
+	frame := viz.GridFrame(matrix)
+	frame.Caption = caption
+	frame.Overlays = []viz.Overlay{viz.Highlight("found", found, 0, viz.Red)}
+	if err := rend.Render(frame); err != nil {
+		rend.Close()
+		return err
 	}
-	return true
+	return rend.Close()
 }
@@ -0,0 +1,55 @@
+package day4
+
+import (
+	"io"
+	"math/rand/v2"
+
+	"go.coldcutz.net/advent2024/common"
+)
+
+// Generate writes a size x size grid of the letters XMAS, with about size XMASes planted in random directions and
+// about size/2 X-MASes planted on top of those.
+func Generate(w io.Writer, size int, rng *rand.Rand) error {
+	const letters = "XMAS"
+	size = max(size, 4)
+	grid := make([][]byte, size)
+	for y := range grid {
+		grid[y] = make([]byte, size)
+		for x := range grid[y] {
+			grid[y][x] = letters[rng.IntN(len(letters))]
+		}
+	}
+
+	for range size {
+		d := common.Dirs8[rng.IntN(len(common.Dirs8))]
+		// pick a start that keeps the whole word on the grid
+		lo := func(step int) int { return max(0, -step*(len(letters)-1)) }
+		hi := func(step int) int { return size - max(0, step*(len(letters)-1)) }
+		p := common.Point{X: lo(d.X) + rng.IntN(hi(d.X)-lo(d.X)), Y: lo(d.Y) + rng.IntN(hi(d.Y)-lo(d.Y))}
+		for i := range len(letters) {
+			q := p.Add(d.Scale(i))
+			grid[q.Y][q.X] = letters[i]
+		}
+	}
+
+	for range size / 2 {
+		x, y := 1+rng.IntN(size-2), 1+rng.IntN(size-2)
+		grid[y][x] = 'A'
+		// each diagonal is M-A-S one way or the other
+		for _, diag := range [][2]common.Point{{common.UpLeft, common.DownRight}, {common.UpRight, common.DownLeft}} {
+			m, s := diag[0], diag[1]
+			if rng.IntN(2) == 0 {
+				m, s = s, m
+			}
+			grid[y+m.Y][x+m.X] = 'M'
+			grid[y+s.Y][x+s.X] = 'S'
+		}
+	}
+
+	for _, row := range grid {
+		if _, err := w.Write(append(row, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,12 +2,12 @@ package day5
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"math/rand/v2"
-	"strconv"
-	"strings"
 
 	"go.coldcutz.net/advent2024/common"
+	"go.coldcutz.net/advent2024/common/parse"
 )
 
 var Solutions = common.Solutions{
@@ -24,54 +24,56 @@ type ruleset []rule
 type update []int
 
 func parseRule(line string) (rule, error) {
-	parts := strings.Split(line, "|")
-	a, err := strconv.Atoi(parts[0])
+	parts, err := parse.IntList(line, "|")
 	if err != nil {
 		return rule{}, err
 	}
-	b, err := strconv.Atoi(parts[1])
-	if err != nil {
-		return rule{}, err
+	if len(parts) != 2 {
+		return rule{}, fmt.Errorf("invalid rule: %s", line)
 	}
-	return rule{a, b}, nil
+	return rule{parts[0], parts[1]}, nil
 }
 
 func parseUpdate(line string) (update, error) {
-	parts := strings.Split(line, ",")
-	u := make(update, 0, len(parts))
-	for _, p := range parts {
-		v, err := strconv.Atoi(p)
-		if err != nil {
-			return nil, err
-		}
-		u = append(u, v)
-	}
-	return u, nil
+	return parse.IntList(line, ",")
 }
 
-func parseInput(cnt []byte) (ruleset, []update, error) {
+// parseInput reads the rules section and then the updates section
+func parseInput(opts common.Opts) (ruleset, []update, error) {
+	f, err := common.OpenInput(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
 	rules := []rule{}
 	updates := []update{}
 
-	ruleSection := true
-	for _, line := range strings.Split(string(cnt), "\n") {
-		if len(line) == 0 {
-			ruleSection = false
-			continue
-		}
-		if ruleSection {
-			r, err := parseRule(line)
-			if err != nil {
-				return nil, nil, err
-			}
-			rules = append(rules, r)
-		} else {
-			u, err := parseUpdate(line)
-			if err != nil {
-				return nil, nil, err
+	section := 0
+	records, recordsErr := common.Records(f)
+	for start, lines := range records {
+		for i, line := range lines {
+			switch section {
+			case 0:
+				r, err := parseRule(line)
+				if err != nil {
+					return nil, nil, parse.AtLine(start+i, err)
+				}
+				rules = append(rules, r)
+			case 1:
+				u, err := parseUpdate(line)
+				if err != nil {
+					return nil, nil, parse.AtLine(start+i, err)
+				}
+				updates = append(updates, u)
+			default:
+				return nil, nil, parse.AtLine(start, fmt.Errorf("unexpected section"))
 			}
-			updates = append(updates, u)
 		}
+		section++
+	}
+	if err := recordsErr(); err != nil {
+		return nil, nil, err
 	}
 
 	return rules, updates, nil
@@ -89,14 +91,10 @@ func (rs ruleset) check(u update) bool {
 	for _, r := range rs {
 		rulemap[r.b] = append(rulemap[r.b], r.a)
 	}
-	forbidden := make(map[int]struct{})
+	forbidden := common.Set[int]{}
 	for _, n := range u {
-		if no, ok := rulemap[n]; ok {
-			for _, fn := range no {
-				forbidden[fn] = struct{}{}
-			}
-		}
-		if _, ok := forbidden[n]; ok {
+		forbidden.Add(rulemap[n]...)
+		if forbidden.Has(n) {
 			return false
 		}
 	}
@@ -109,14 +107,10 @@ func (rs ruleset) checkUpTo(u update, upTo int) bool {
 	for _, r := range rs {
 		rulemap[r.b] = append(rulemap[r.b], r.a)
 	}
-	forbidden := make(map[int]struct{})
+	forbidden := common.Set[int]{}
 	for _, n := range u[:upTo] {
-		if no, ok := rulemap[n]; ok {
-			for _, fn := range no {
-				forbidden[fn] = struct{}{}
-			}
-		}
-		if _, ok := forbidden[n]; ok {
+		forbidden.Add(rulemap[n]...)
+		if forbidden.Has(n) {
 			return false
 		}
 	}
@@ -125,12 +119,7 @@ func (rs ruleset) checkUpTo(u update, upTo int) bool {
 }
 
 func Part1(ctx context.Context, log *slog.Logger, opts common.Opts) error {
-	cnt, err := common.ReadAllInput(opts)
-	if err != nil {
-		return err
-	}
-
-	rules, updates, err := parseInput(cnt)
+	rules, updates, err := parseInput(opts)
 	if err != nil {
 		return err
 	}
@@ -150,7 +139,7 @@ func Part1(ctx context.Context, log *slog.Logger, opts common.Opts) error {
 	return nil
 }
 
-func (rs ruleset) fix(u update, log *slog.Logger) update {
+func (rs ruleset) fix(u update, rng *rand.Rand, log *slog.Logger) update {
 	// lets try swapping stuff until its ok
 	// a|b
 	// c|d
@@ -196,10 +185,10 @@ func (rs ruleset) fix(u update, log *slog.Logger) update {
 				continue
 			}
 
-			swapWith := actuallyPossibleSwaps[rand.IntN(len(actuallyPossibleSwaps))]
+			swapWith := actuallyPossibleSwaps[rng.IntN(len(actuallyPossibleSwaps))]
 			swapWithIdxs := indexed[swapWith]
 			// pick one
-			swapWithIdx := swapWithIdxs[rand.IntN(len(swapWithIdxs))]
+			swapWithIdx := swapWithIdxs[rng.IntN(len(swapWithIdxs))]
 
 			log.Debug("swapping", "ui", u[i], "si", swapWith, "u", u)
 
@@ -218,22 +207,19 @@ func (rs ruleset) fix(u update, log *slog.Logger) update {
 }
 
 func Part2(ctx context.Context, log *slog.Logger, opts common.Opts) error {
-	cnt, err := common.ReadAllInput(opts)
+	rules, updates, err := parseInput(opts)
 	if err != nil {
 		return err
 	}
 
-	rules, updates, err := parseInput(cnt)
-	if err != nil {
-		return err
-	}
+	rng := common.NewRand(opts, log)
 
 	sum := 0
 	for _, u := range updates {
 		if rules.check(u) {
 			continue
 		}
-		u := rules.fix(u, log)
+		u := rules.fix(u, rng, log)
 		sum += u.median()
 	}
 
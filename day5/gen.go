@@ -0,0 +1,58 @@
+package day5
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Generate writes ordering rules for 49 pages and then size updates. The rules are every pair from one random
+// total order, so they're acyclic and cover every pair in an update, which is what Part2's fixing relies on. About
+// half the updates are already correctly ordered.
+func Generate(w io.Writer, size int, rng *rand.Rand) error {
+	const numPages = 49
+	pages := rng.Perm(90)[:numPages]
+	for i := range pages {
+		pages[i] += 10
+	}
+	rank := map[int]int{}
+	for i, p := range pages {
+		rank[p] = i
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, i := range rng.Perm(numPages) {
+		for _, j := range rng.Perm(numPages) {
+			if i < j {
+				if _, err := fmt.Fprintf(bw, "%d|%d\n", pages[i], pages[j]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if _, err := bw.WriteString("\n"); err != nil {
+		return err
+	}
+
+	for range size {
+		n := 5 + 2*rng.IntN(10) // odd, so there's a middle page
+		u := slices.Clone(pages)
+		rng.Shuffle(len(u), func(i, j int) { u[i], u[j] = u[j], u[i] })
+		u = u[:n]
+		if rng.IntN(2) == 0 {
+			slices.SortFunc(u, func(a, b int) int { return rank[a] - rank[b] })
+		}
+		strs := make([]string, n)
+		for i, p := range u {
+			strs[i] = strconv.Itoa(p)
+		}
+		if _, err := bw.WriteString(strings.Join(strs, ",") + "\n"); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"go.coldcutz.net/advent2024/common"
+	"go.coldcutz.net/advent2024/common/viz"
 )
 
 var Solutions = common.Solutions{
@@ -40,6 +41,22 @@ func (ge gridEntry) dirInc(p pos) pos {
 	}
 }
 
+// dirIndex numbers the guard's headings, for indexing per-direction state
+func (ge gridEntry) dirIndex() int {
+	switch ge {
+	case guardUp:
+		return 0
+	case guardRight:
+		return 1
+	case guardDown:
+		return 2
+	case guardLeft:
+		return 3
+	default:
+		panic("invalid gridEntry")
+	}
+}
+
 func (ge gridEntry) turnRight() gridEntry {
 	switch ge {
 	case guardUp:
@@ -55,6 +72,12 @@ func (ge gridEntry) turnRight() gridEntry {
 	}
 }
 
+// heading is the direction the guard is facing, as a unit step
+func (ge gridEntry) heading() common.Point {
+	d := ge.dirInc(pos{0, 0})
+	return common.Point{X: d[0], Y: d[1]}
+}
+
 type grid [][]gridEntry
 
 func (g grid) at(p pos) gridEntry {
@@ -93,8 +116,26 @@ func (g grid) String() string {
 
 type pos [2]int
 
-func simulateGuard(grid grid, startingPos pos, startingDir gridEntry, log *slog.Logger) int {
-	placesVisited := map[pos]struct{}{}
+func (p pos) point() common.Point {
+	return common.Point{X: p[0], Y: p[1]}
+}
+
+func render(rend viz.Renderer, f viz.Frame, log *slog.Logger) {
+	if err := rend.Render(f); err != nil {
+		log.Warn("failed to render frame", "error", err)
+	}
+}
+
+func simulateGuard(grid grid, startingPos pos, startingDir gridEntry, rend viz.Renderer, log *slog.Logger) int {
+	placesVisited := common.NewGridBitset(len(grid[0]), len(grid), 1)
+
+	// only tracked when rendering
+	var frame viz.Frame
+	var path []common.Point
+	if rend != nil {
+		frame = viz.GridFrame(grid)
+		path = append(path, startingPos.point())
+	}
 
 	// guard starts at startingPos
 	// guard moves in direction of facing
@@ -106,7 +147,7 @@ func simulateGuard(grid grid, startingPos pos, startingDir gridEntry, log *slog.
 
 		// goes offscreen -- we're done
 		if nextPos[0] >= len(grid[0]) || nextPos[1] >= len(grid) || nextPos[0] < 0 || nextPos[1] < 0 {
-			return len(placesVisited) + 1 // +1 for the starting position
+			return placesVisited.Count() + 1 // +1 for the starting position
 		}
 
 		nextEntry := grid.at(nextPos)
@@ -116,8 +157,19 @@ func simulateGuard(grid grid, startingPos pos, startingDir gridEntry, log *slog.
 		}
 
 		// move to next position
-		placesVisited[curPos] = struct{}{}
+		placesVisited.Set(curPos[0], curPos[1], 0)
 		curPos = nextPos
+
+		if rend != nil {
+			path = append(path, curPos.point())
+			frame.Step++
+			frame.Caption = fmt.Sprintf("visited %d", placesVisited.Count()+1)
+			frame.Overlays = []viz.Overlay{
+				viz.Highlight("visited", path, 'X', viz.Blue),
+				viz.Heading(curPos.point(), curDir.heading(), viz.Yellow),
+			}
+			render(rend, frame, log)
+		}
 	}
 }
 
@@ -137,7 +189,7 @@ func Part1(ctx context.Context, log *slog.Logger, opts common.Opts) error {
 		grid = append(grid, []gridEntry(line))
 	}
 
-	fmt.Println(grid)
+	log.Debug("parsed", "grid", grid)
 
 	// find guard's starting position
 	guardPos := pos{-1, -1}
@@ -155,26 +207,47 @@ func Part1(ctx context.Context, log *slog.Logger, opts common.Opts) error {
 		return fmt.Errorf("no guard")
 	}
 
-	count := simulateGuard(grid, guardPos, guardDir, log)
+	rend, err := viz.Open(opts.Viz, opts.Render, opts.FPS)
+	if err != nil {
+		return err
+	}
+
+	count := simulateGuard(grid, guardPos, guardDir, rend, log)
+	if rend != nil {
+		if err := rend.Close(); err != nil {
+			return err
+		}
+	}
 
 	log.Info("result", "count", count)
 
 	return nil
 }
 
-func simulateGuardStuck(gr grid, startingPos pos, startingDir gridEntry, log *slog.Logger) int {
+func simulateGuardStuck(gr grid, startingPos pos, startingDir gridEntry, rend viz.Renderer, log *slog.Logger) int {
 	// same as simulateGuard, but at each step see if adding an obstacle there gets the guard stuck in a loop
-	loopsFound := map[pos]struct{}{}
+	width, height := len(gr[0]), len(gr)
+	loopsFound := common.NewGridBitset(width, height, 1)
+	placesVisited := common.NewGridBitset(width, height, 1)
+	// scratch space for doesGuardLoop, reused so that checking a candidate doesn't allocate
+	visitedDirs := common.NewGridBitset(width, height, 4)
+
+	// only tracked when rendering
+	var frame viz.Frame
+	var path, loops []common.Point
+	if rend != nil {
+		frame = viz.GridFrame(gr)
+		path = append(path, startingPos.point())
+	}
 
-	placesVisited := map[pos]struct{}{}
 	curPos := startingPos
 	curDir := startingDir
 	for {
 		nextPos := curDir.dirInc(curPos)
 
 		// goes offscreen -- we're done
-		if nextPos[0] >= len(gr[0]) || nextPos[1] >= len(gr) || nextPos[0] < 0 || nextPos[1] < 0 {
-			return len(loopsFound)
+		if nextPos[0] >= width || nextPos[1] >= height || nextPos[0] < 0 || nextPos[1] < 0 {
+			return loopsFound.Count()
 		}
 
 		nextEntry := gr.at(nextPos)
@@ -184,26 +257,46 @@ func simulateGuardStuck(gr grid, startingPos pos, startingDir gridEntry, log *sl
 		}
 
 		// check if adding an obstacle here would get the guard stuck
-		if _, ok := loopsFound[nextPos]; !ok {
+		if !loopsFound.Test(nextPos[0], nextPos[1], 0) {
 			if nextPos != startingPos { // don't add obstacle at starting position
-				grClone := gr.clone()
-				grClone.set(nextPos, obstacle)
-				if doesGuardLoop(grClone, startingPos, startingDir, log) {
+				gr.set(nextPos, obstacle)
+				looped := doesGuardLoop(gr, visitedDirs, startingPos, startingDir, log)
+				if looped {
 					log.Debug("found loop by adding obstacle", "pos", nextPos)
-					loopsFound[nextPos] = struct{}{}
+					loopsFound.Set(nextPos[0], nextPos[1], 0)
+				}
+				gr.set(nextPos, nextEntry)
+
+				if rend != nil {
+					if looped {
+						loops = append(loops, nextPos.point())
+					}
+					frame.Step++
+					frame.Caption = fmt.Sprintf("loops found %d", loopsFound.Count())
+					frame.Overlays = []viz.Overlay{
+						viz.Highlight("visited", path, 'X', viz.Blue),
+						viz.Highlight("loops", loops, 'O', viz.Red),
+						viz.Highlight("candidate", []common.Point{nextPos.point()}, 'O', viz.Yellow),
+						viz.Heading(curPos.point(), curDir.heading(), viz.Green),
+					}
+					render(rend, frame, log)
 				}
 			}
 		}
 
 		// move to next position
-		placesVisited[curPos] = struct{}{}
+		placesVisited.Set(curPos[0], curPos[1], 0)
 		curPos = nextPos
+		if rend != nil {
+			path = append(path, curPos.point())
+		}
 	}
 }
 
-func doesGuardLoop(grid grid, startingPos pos, startingDir gridEntry, log *slog.Logger) bool {
+// doesGuardLoop resets and uses visitedDirs, which must have a layer per direction
+func doesGuardLoop(grid grid, visitedDirs *common.GridBitset, startingPos pos, startingDir gridEntry, log *slog.Logger) bool {
 	// pos -> set of directions we've been in at that pos. if we hit a pos/direction combo we've been in before, we will loop
-	visitedDirs := map[pos]map[gridEntry]struct{}{}
+	visitedDirs.Reset()
 
 	curPos := startingPos
 	curDir := startingDir
@@ -222,23 +315,83 @@ func doesGuardLoop(grid grid, startingPos pos, startingDir gridEntry, log *slog.
 			continue
 		}
 
-		// will we loop?
-		if prevDirs, ok := visitedDirs[curPos]; ok {
-			for prevDir := range prevDirs {
-				if prevDir == curDir { // we looped
-					return true
-				}
+		// will we loop? if not, move to next position
+		if !visitedDirs.Set(curPos[0], curPos[1], curDir.dirIndex()) {
+			return true
+		}
+		curPos = nextPos
+	}
+
+}
+
+// simulateGuardMaps is simulateGuard as it was before the bitsets, tracking positions in a set. It's kept as a
+// reference to check and benchmark simulateGuard against.
+func simulateGuardMaps(grid grid, startingPos pos, startingDir gridEntry) int {
+	placesVisited := common.Set[pos]{}
+	curPos := startingPos
+	curDir := startingDir
+	for {
+		nextPos := curDir.dirInc(curPos)
+		if nextPos[0] >= len(grid[0]) || nextPos[1] >= len(grid) || nextPos[0] < 0 || nextPos[1] < 0 {
+			return placesVisited.Len() + 1 // +1 for the starting position
+		}
+		if grid.at(nextPos) == obstacle {
+			curDir = curDir.turnRight()
+			continue
+		}
+		placesVisited.Add(curPos)
+		curPos = nextPos
+	}
+}
+
+// simulateGuardStuckMaps is simulateGuardStuck as it was before the bitsets: sets and maps for the visited state,
+// and a fresh copy of the grid for every candidate obstacle. It's kept as a reference like simulateGuardMaps.
+func simulateGuardStuckMaps(gr grid, startingPos pos, startingDir gridEntry) int {
+	loopsFound := common.Set[pos]{}
+	curPos := startingPos
+	curDir := startingDir
+	for {
+		nextPos := curDir.dirInc(curPos)
+		if nextPos[0] >= len(gr[0]) || nextPos[1] >= len(gr) || nextPos[0] < 0 || nextPos[1] < 0 {
+			return loopsFound.Len()
+		}
+		if gr.at(nextPos) == obstacle {
+			curDir = curDir.turnRight()
+			continue
+		}
+		if !loopsFound.Has(nextPos) && nextPos != startingPos {
+			grClone := gr.clone()
+			grClone.set(nextPos, obstacle)
+			if doesGuardLoopMaps(grClone, startingPos, startingDir) {
+				loopsFound.Add(nextPos)
 			}
 		}
+		curPos = nextPos
+	}
+}
 
-		// move to next position
+func doesGuardLoopMaps(grid grid, startingPos pos, startingDir gridEntry) bool {
+	visitedDirs := map[pos]common.Set[gridEntry]{}
+	curPos := startingPos
+	curDir := startingDir
+	for {
+		nextPos := curDir.dirInc(curPos)
+		if nextPos[0] >= len(grid[0]) || nextPos[1] >= len(grid) || nextPos[0] < 0 || nextPos[1] < 0 {
+			return false
+		}
+		if grid.at(nextPos) == obstacle {
+			curDir = curDir.turnRight()
+			continue
+		}
+		if visitedDirs[curPos].Has(curDir) {
+			return true
+		}
 		if _, ok := visitedDirs[curPos]; !ok {
-			visitedDirs[curPos] = map[gridEntry]struct{}{}
+			visitedDirs[curPos] = common.Set[gridEntry]{}
 		}
-		visitedDirs[curPos][curDir] = struct{}{}
+		visitedDirs[curPos].Add(curDir)
 		curPos = nextPos
 	}
-
 }
 
 func Part2(ctx context.Context, log *slog.Logger, opts common.Opts) error {
@@ -257,7 +410,7 @@ func Part2(ctx context.Context, log *slog.Logger, opts common.Opts) error {
 		grid = append(grid, []gridEntry(line))
 	}
 
-	fmt.Println(grid)
+	log.Debug("parsed", "grid", grid)
 
 	// find guard's starting position
 	guardPos := pos{-1, -1}
@@ -275,7 +428,17 @@ func Part2(ctx context.Context, log *slog.Logger, opts common.Opts) error {
 		return fmt.Errorf("no guard")
 	}
 
-	count := simulateGuardStuck(grid, guardPos, guardDir, log)
+	rend, err := viz.Open(opts.Viz, opts.Render, opts.FPS)
+	if err != nil {
+		return err
+	}
+
+	count := simulateGuardStuck(grid, guardPos, guardDir, rend, log)
+	if rend != nil {
+		if err := rend.Close(); err != nil {
+			return err
+		}
+	}
 
 	log.Info("result", "count", count)
 
@@ -0,0 +1,101 @@
+package day6
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"go.coldcutz.net/advent2024/common"
+)
+
+var quiet = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// loadGrid reads a map and finds the guard, the same way the parts do
+func loadGrid(tb testing.TB, path string) (grid, pos, gridEntry) {
+	cnt, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	g := grid{}
+	for _, line := range strings.Split(string(cnt), "\n") {
+		if len(line) > 0 {
+			g = append(g, []gridEntry(line))
+		}
+	}
+	for y, row := range g {
+		for x, entry := range row {
+			switch entry {
+			case guardUp, guardDown, guardLeft, guardRight:
+				return g, pos{x, y}, entry
+			}
+		}
+	}
+	tb.Fatalf("%s: no guard", path)
+	return nil, pos{}, 0
+}
+
+func TestMatchesMaps(t *testing.T) {
+	for seed := range uint64(20) {
+		opts, err := common.Fixture(Generate, 40, seed, t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		g, start, dir := loadGrid(t, opts.Input)
+		if got, want := simulateGuard(g, start, dir, nil, quiet), simulateGuardMaps(g, start, dir); got != want {
+			t.Errorf("seed %d: simulateGuard = %d, maps version = %d", seed, got, want)
+		}
+		if got, want := simulateGuardStuck(g, start, dir, nil, quiet), simulateGuardStuckMaps(g, start, dir); got != want {
+			t.Errorf("seed %d: simulateGuardStuck = %d, maps version = %d", seed, got, want)
+		}
+	}
+}
+
+func benchmarkPart(b *testing.B, solve func(context.Context, *slog.Logger, common.Opts) error) {
+	opts := common.Opts{Input: "input.txt"}
+	for range b.N {
+		if err := solve(context.Background(), quiet, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPart1(b *testing.B) {
+	benchmarkPart(b, Part1)
+}
+
+func BenchmarkPart2(b *testing.B) {
+	benchmarkPart(b, Part2)
+}
+
+// the simulations alone, against the map-based versions they replaced
+
+func BenchmarkSimulateGuard(b *testing.B) {
+	g, start, dir := loadGrid(b, "input.txt")
+	for range b.N {
+		simulateGuard(g, start, dir, nil, quiet)
+	}
+}
+
+func BenchmarkSimulateGuardMaps(b *testing.B) {
+	g, start, dir := loadGrid(b, "input.txt")
+	for range b.N {
+		simulateGuardMaps(g, start, dir)
+	}
+}
+
+func BenchmarkSimulateGuardStuck(b *testing.B) {
+	g, start, dir := loadGrid(b, "input.txt")
+	for range b.N {
+		simulateGuardStuck(g, start, dir, nil, quiet)
+	}
+}
+
+func BenchmarkSimulateGuardStuckMaps(b *testing.B) {
+	g, start, dir := loadGrid(b, "input.txt")
+	for range b.N {
+		simulateGuardStuckMaps(g, start, dir)
+	}
+}
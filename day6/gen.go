@@ -0,0 +1,48 @@
+package day6
+
+import (
+	"io"
+	"log/slog"
+	"math/rand/v2"
+
+	"go.coldcutz.net/advent2024/common"
+)
+
+// Generate writes a size x size map with roughly one cell in ten an obstacle and the guard somewhere facing up. Maps
+// that trap the guard in a loop are thrown away and redrawn, since part 1 needs the guard to walk off the edge.
+func Generate(w io.Writer, size int, rng *rand.Rand) error {
+	size = max(size, 2)
+	visitedDirs := common.NewGridBitset(size, size, 4)
+	quiet := slog.New(slog.NewTextHandler(io.Discard, nil))
+	g, guard := randomGrid(size, rng)
+	for doesGuardLoop(g, visitedDirs, guard, guardUp, quiet) {
+		g, guard = randomGrid(size, rng)
+	}
+
+	for _, row := range g {
+		line := make([]byte, 0, len(row)+1)
+		for _, entry := range row {
+			line = append(line, byte(entry))
+		}
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func randomGrid(size int, rng *rand.Rand) (grid, pos) {
+	g := make(grid, size)
+	for y := range g {
+		g[y] = make([]gridEntry, size)
+		for x := range g[y] {
+			g[y][x] = empty
+			if rng.IntN(10) == 0 {
+				g[y][x] = obstacle
+			}
+		}
+	}
+	guard := pos{rng.IntN(size), rng.IntN(size)}
+	g[guard[1]][guard[0]] = guardUp
+	return g, guard
+}
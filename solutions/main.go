@@ -25,12 +25,42 @@ var days = map[int]common.Solutions{
 	6: day6.Solutions,
 }
 
+var generators = map[int]common.Generator{
+	1: day1.Generate,
+	2: day2.Generate,
+	3: day3.Generate,
+	4: day4.Generate,
+	5: day5.Generate,
+	6: day6.Generate,
+}
+
 type Opts struct {
 	Day int `short:"d" description:"day" required:"true"`
 	common.Opts
 }
 
+type GenOpts struct {
+	Day    int    `short:"d" description:"day" required:"true"`
+	Size   int    `long:"size" description:"how big an input to make; lines, items or grid side depending on the day" default:"1000"`
+	Seed   uint64 `long:"seed" description:"random seed; 0 picks one (it's logged either way)"`
+	Output string `short:"o" description:"output file (default stdout)"`
+}
+
 func main() {
+	// `solutions gen ...` writes a random input instead of solving one
+	if len(os.Args) > 1 && os.Args[1] == "gen" {
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+		_, log, opts, err := utils.StdSetup[GenOpts]()
+		if err != nil {
+			panic(err)
+		}
+		if err := gen(log, opts); err != nil {
+			log.Error("failed to generate", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	ctx, log, opts, err := utils.StdSetup[Opts]()
 	if err != nil {
 		panic(err)
@@ -54,3 +84,24 @@ func run(ctx context.Context, log *slog.Logger, opts Opts) error {
 	}
 	return soln(ctx, log, opts.Opts)
 }
+
+func gen(log *slog.Logger, opts GenOpts) error {
+	generate, ok := generators[opts.Day]
+	if !ok {
+		return fmt.Errorf("no generator for day %d", opts.Day)
+	}
+
+	rng := common.SeededRand(opts.Seed, log)
+	if opts.Output == "" {
+		return generate(os.Stdout, opts.Size, rng)
+	}
+	f, err := os.Create(opts.Output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := generate(f, opts.Size, rng); err != nil {
+		return err
+	}
+	return f.Close()
+}